@@ -0,0 +1,304 @@
+// Package client is a Go client for the director JSON-RPC API.
+//
+// It sends JSON-RPC POST requests, so parameters don't need the quoting of URI calls,
+// and decodes the results with the types of the director and Tendermint.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"director/m/v2/rpc/core"
+	"director/m/v2/store"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/p2p"
+	tmctypes "github.com/tendermint/tendermint/rpc/core/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPollInterval is how often WaitForGenesis asks for the genesis by default
+const DefaultPollInterval = 5 * time.Second
+
+// Client calls the JSON-RPC API of a director
+type Client struct {
+	remote       string
+	httpClient   *http.Client
+	pollInterval time.Duration
+	cdc          *amino.Codec
+	nextID       int64
+}
+
+// Option is additional parameters to Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for the requests, for example to set a timeout or TLS options
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithPollInterval sets how often WaitForGenesis asks for the genesis
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = interval
+	}
+}
+
+// New returns a client of the director at remote, for example http://localhost:27001.
+// The tcp:// scheme of Tendermint addresses is accepted as http://.
+func New(remote string, options ...Option) (*Client, error) {
+	if strings.HasPrefix(remote, "tcp://") {
+		remote = "http://" + strings.TrimPrefix(remote, "tcp://")
+	}
+	if !strings.Contains(remote, "://") {
+		remote = "http://" + remote
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid remote")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid remote %s: the scheme must be http or https", remote)
+	}
+
+	cdc := amino.NewCodec()
+	tmctypes.RegisterAmino(cdc)
+	c := &Client{
+		remote:       strings.TrimSuffix(u.String(), "/"),
+		httpClient:   http.DefaultClient,
+		pollInterval: DefaultPollInterval,
+		cdc:          cdc,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// Registration holds the parameters of a registration besides the chain ID.
+// Only Name and PubKey are required. See core.Register for the meaning of the others.
+type Registration struct {
+	Name           string
+	PubKey         string
+	KeyType        string
+	NetAddress     string
+	Seed           bool
+	AccountAddress string
+	GenTx          json.RawMessage
+	Power          int64
+	Role           string
+	Sentries       []string
+	Private        bool
+}
+
+// Register registers a node for a testnet
+func (c *Client) Register(ctx context.Context, chainID string, registration Registration) (*core.ResultRegister, error) {
+	result := &core.ResultRegister{}
+	err := c.call(ctx, "register", map[string]interface{}{
+		"chain_id":        chainID,
+		"name":            registration.Name,
+		"pub_key":         registration.PubKey,
+		"net_address":     registration.NetAddress,
+		"seed":            registration.Seed,
+		"key_type":        registration.KeyType,
+		"account_address": registration.AccountAddress,
+		"gentx":           string(registration.GenTx),
+		"power":           registration.Power,
+		"role":            registration.Role,
+		"sentries":        strings.Join(registration.Sentries, ","),
+		"private":         registration.Private,
+	}, result)
+	return result, err
+}
+
+// RegisterJSON registers a node for a testnet with the public parts of its Tendermint key files.
+// The network address is built from host and port, the PubKey, KeyType and NetAddress of the registration are ignored.
+func (c *Client) RegisterJSON(ctx context.Context, chainID string, privValidatorKey core.PrivValidatorKeyJSON, nodeKey core.NodeKeyJSON, host string, port uint16, registration Registration) (*core.ResultRegister, error) {
+	result := &core.ResultRegister{}
+	err := c.call(ctx, "register_json", map[string]interface{}{
+		"chain_id":           chainID,
+		"name":               registration.Name,
+		"priv_validator_key": privValidatorKey,
+		"node_key":           nodeKey,
+		"host":               host,
+		"port":               port,
+		"seed":               registration.Seed,
+		"account_address":    registration.AccountAddress,
+		"gentx":              string(registration.GenTx),
+		"power":              registration.Power,
+		"role":               registration.Role,
+		"sentries":           strings.Join(registration.Sentries, ","),
+		"private":            registration.Private,
+	}, result)
+	return result, err
+}
+
+// Genesis returns the genesis of a testnet. It fails with types.CodeTestnetNotReady until the genesis is compiled.
+func (c *Client) Genesis(ctx context.Context, chainID string) (*tmctypes.ResultGenesis, error) {
+	result := &tmctypes.ResultGenesis{}
+	err := c.call(ctx, "genesis", map[string]interface{}{"chain_id": chainID}, result)
+	return result, err
+}
+
+// WaitForGenesis waits until the genesis of a testnet is compiled and returns it.
+// It fails with the first error other than types.CodeTestnetNotReady, or when ctx is done.
+func (c *Client) WaitForGenesis(ctx context.Context, chainID string) (*tmctypes.ResultGenesis, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		genesis, err := c.Genesis(ctx, chainID)
+		if ErrorCode(err) != types.CodeTestnetNotReady {
+			return genesis, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// AddressBook returns the addrbook.json of a testnet
+func (c *Client) AddressBook(ctx context.Context, chainID string) (*store.AddrBookJSON, error) {
+	result := &store.AddrBookJSON{}
+	err := c.call(ctx, "addrbook", map[string]interface{}{"chain_id": chainID}, result)
+	return result, err
+}
+
+// Peers returns the persistent_peers string of a testnet. See core.Peers for the options.
+func (c *Client) Peers(ctx context.Context, chainID string, exclude p2p.ID, limit int, seedsOnly bool) (*core.ResultPeers, error) {
+	result := &core.ResultPeers{}
+	err := c.call(ctx, "peers", map[string]interface{}{
+		"chain_id":   chainID,
+		"exclude":    string(exclude),
+		"limit":      limit,
+		"seeds_only": seedsOnly,
+	}, result)
+	return result, err
+}
+
+// NodeConfig returns the Tendermint config.toml of a node registered with pubKey or nodeID
+func (c *Client) NodeConfig(ctx context.Context, chainID string, pubKey string, nodeID p2p.ID) (*core.ResultNodeConfig, error) {
+	result := &core.ResultNodeConfig{}
+	err := c.call(ctx, "node_config", map[string]interface{}{
+		"chain_id": chainID,
+		"pub_key":  pubKey,
+		"node_id":  string(nodeID),
+	}, result)
+	return result, err
+}
+
+// Status returns the state and the registration progress of a testnet
+func (c *Client) Status(ctx context.Context, chainID string) (*core.ResultStatus, error) {
+	result := &core.ResultStatus{}
+	err := c.call(ctx, "status", map[string]interface{}{"chain_id": chainID}, result)
+	return result, err
+}
+
+// Testnets returns the status of every testnet
+func (c *Client) Testnets(ctx context.Context) (*core.ResultTestnets, error) {
+	result := &core.ResultTestnets{}
+	err := c.call(ctx, "testnets", map[string]interface{}{}, result)
+	return result, err
+}
+
+// File downloads a file of a testnet, for example genesis.json or addrbook.json, and checks it against
+// the SHA-256 checksum published by the director
+func (c *Client) File(ctx context.Context, chainID string, name string) ([]byte, error) {
+	content, err := c.get(ctx, core.FilesPath+url.PathEscape(chainID)+"/"+name)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := c.get(ctx, core.FilesPath+url.PathEscape(chainID)+"/"+name+".sha256")
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 || fields[0] != fmt.Sprintf("%x", sha256.Sum256(content)) {
+		return nil, fmt.Errorf("checksum mismatch for %s of %s", name, chainID)
+	}
+	return content, nil
+}
+
+//------------------------------------------------------------------------------
+
+// rpcResponse is a JSON-RPC response
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// call sends a JSON-RPC request and decodes its result
+func (c *Client) call(ctx context.Context, method string, params map[string]interface{}, result interface{}) error {
+	id := rpctypes.JSONRPCIntID(atomic.AddInt64(&c.nextID, 1))
+	request, err := rpctypes.MapToRequest(c.cdc, id, method, params)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode params")
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal request")
+	}
+	httpRequest, err := http.NewRequest(http.MethodPost, c.remote, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	content, status, err := c.do(ctx, httpRequest)
+	if err != nil {
+		return err
+	}
+
+	var response rpcResponse
+	if err := json.Unmarshal(content, &response); err != nil {
+		return fmt.Errorf("unexpected response from %s (HTTP %d): %s", c.remote, status, strings.TrimSpace(string(content)))
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if err := c.cdc.UnmarshalJSON(response.Result, result); err != nil {
+		return errors.Wrapf(err, "failed to decode the result of %s", method)
+	}
+	return nil
+}
+
+// get downloads a path of the director
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	httpRequest, err := http.NewRequest(http.MethodGet, c.remote+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	content, status, err := c.do(ctx, httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s (HTTP %d): %s", path, status, strings.TrimSpace(string(content)))
+	}
+	return content, nil
+}
+
+func (c *Client) do(ctx context.Context, httpRequest *http.Request) ([]byte, int, error) {
+	httpResponse, err := c.httpClient.Do(httpRequest.WithContext(ctx))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer httpResponse.Body.Close() // nolint: errcheck
+
+	content, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read response body")
+	}
+	return content, httpResponse.StatusCode, nil
+}
@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"director/m/v2/client"
+	cfg "director/m/v2/config"
+	"director/m/v2/node"
+	"director/m/v2/types"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+)
+
+// startTestNode starts a director on a random port with a "default" testnet of two validators and returns its address
+func startTestNode(t *testing.T) (string, func()) {
+	home, err := ioutil.TempDir("", "director-client")
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	config := cfg.DefaultConfig().SetRoot(home)
+	config.DBBackend = cfg.DBBackendJSON
+	config.RPC.ListenAddress = "tcp://" + address
+	config.RPC.RateLimitPerIP = 0
+	config.RPC.RateLimitPerChain = 0
+	heartbeat := 10 * time.Millisecond
+	config.StateMachineHeartbeat = &heartbeat
+	*config.Testnets = map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 2}}
+	n, err := node.DefaultNewNode(config, log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, n.Start())
+	return "http://" + address, func() {
+		n.Stop()           // nolint: errcheck
+		os.RemoveAll(home) // nolint: errcheck
+	}
+}
+
+// newTestRegistration returns a validator registration with new keys
+func newTestRegistration(t *testing.T, name string) client.Registration {
+	_, pubKey, err := types.PubKeyToBase64(ed25519.GenPrivKey().PubKey())
+	require.NoError(t, err)
+	nodeID := p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())
+	return client.Registration{
+		Name:       name,
+		PubKey:     pubKey,
+		NetAddress: p2p.IDAddressString(nodeID, "127.0.0.1:26656"),
+	}
+}
+
+func TestRegisterAndWaitForGenesis(t *testing.T) {
+	remote, stop := startTestNode(t)
+	defer stop()
+	c, err := client.New(remote, client.WithPollInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := c.Register(ctx, "default", newTestRegistration(t, "validator1"))
+	require.NoError(t, err)
+	assert.Equal(t, "default", res.ChainID)
+	assert.NotEmpty(t, res.NodeID)
+
+	// The genesis is compiled with the second validator
+	_, err = c.Genesis(ctx, "default")
+	require.Error(t, err)
+	assert.Equal(t, types.CodeTestnetNotReady, client.ErrorCode(err))
+
+	genesis := make(chan error, 1)
+	go func() {
+		res, err := c.WaitForGenesis(ctx, "default")
+		if err == nil && len(res.Genesis.Validators) != 2 {
+			err = fmt.Errorf("genesis with %d validators", len(res.Genesis.Validators))
+		}
+		genesis <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_, err = c.Register(ctx, "default", newTestRegistration(t, "validator2"))
+	require.NoError(t, err)
+	require.NoError(t, <-genesis)
+
+	content, err := c.File(ctx, "default", "genesis.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"chain_id": "default"`)
+}
+
+func TestErrors(t *testing.T) {
+	remote, stop := startTestNode(t)
+	defer stop()
+	c, err := client.New(remote)
+	require.NoError(t, err)
+
+	_, err = c.Genesis(context.Background(), "unknown")
+	require.Error(t, err)
+	assert.Equal(t, types.CodeUnregisteredTestnet, client.ErrorCode(err))
+	var e *client.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, "unknown", e.Data.ChainID)
+
+	// WaitForGenesis returns the errors other than CodeTestnetNotReady right away
+	_, err = c.WaitForGenesis(context.Background(), "unknown")
+	assert.Equal(t, types.CodeUnregisteredTestnet, client.ErrorCode(err))
+
+	_, err = c.Register(context.Background(), "default", client.Registration{Name: "validator1", PubKey: "invalid"})
+	require.Error(t, err)
+	assert.Equal(t, types.CodeInvalidParam, client.ErrorCode(err))
+
+	assert.Equal(t, types.ErrorCode(0), client.ErrorCode(nil))
+}
+
+func TestFileChecksum(t *testing.T) {
+	content := []byte(`{"chain_id": "default"}`)
+	checksum := fmt.Sprintf("%x", sha256.Sum256(content))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/default/genesis.json", "/files/corrupted/genesis.json":
+			w.Write(content) // nolint: errcheck
+		case "/files/default/genesis.json.sha256":
+			fmt.Fprintf(w, "%s  genesis.json\n", checksum)
+		case "/files/corrupted/genesis.json.sha256":
+			fmt.Fprintf(w, "%s  genesis.json\n", strings.Repeat("0", len(checksum)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+
+	file, err := c.File(context.Background(), "default", "genesis.json")
+	require.NoError(t, err)
+	assert.Equal(t, content, file)
+
+	_, err = c.File(context.Background(), "corrupted", "genesis.json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+
+	_, err = c.File(context.Background(), "default", "addrbook.json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 404")
+}
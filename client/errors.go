@@ -0,0 +1,29 @@
+package client
+
+import (
+	"director/m/v2/types"
+	"fmt"
+	"github.com/pkg/errors"
+)
+
+// Error is an error returned by the director. Typed errors have a stable code, see the Errors section of the README.
+type Error struct {
+	Code    types.ErrorCode `json:"code"`
+	Message string          `json:"message"`
+	// Details of a typed error
+	Data types.ErrorData `json:"data"`
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return fmt.Sprintf("director error %d: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the code of an Error, or 0 if err is nil or not returned by the director
+func ErrorCode(err error) types.ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return 0
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"director/m/v2/client"
+	cfg "director/m/v2/config"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	tmcfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/cli"
+	tmflags "github.com/tendermint/tendermint/libs/cli/flags"
+	"github.com/tendermint/tendermint/libs/log"
+	tmos "github.com/tendermint/tendermint/libs/os"
+	"os"
+	"path/filepath"
+)
+
+// ClientCmd groups the commands validators run against a remote director.
+// They work on the local Tendermint home given by --tendermint-home instead of the director home.
+var ClientCmd = &cobra.Command{
+	Use:   "client",
+	Short: "Register a Tendermint node with a director and fetch its testnet files",
+	// The client commands don't read the director config, but their flags can be set with DIRECTOR_* variables
+	// like the flags of the other commands, for example DIRECTOR_CHAIN_ID
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
+		if err := viper.BindPFlags(cmd.Flags()); err != nil {
+			return err
+		}
+		logger, err = tmflags.ParseLogLevel(viper.GetString("log_level"), logger, cfg.DefaultLogLevel())
+		if err != nil {
+			return err
+		}
+		if viper.GetBool(cli.TraceFlag) {
+			logger = log.NewTracingLogger(logger)
+		}
+		logger = logger.With("module", "main")
+		return nil
+	},
+}
+
+func init() {
+	ClientCmd.PersistentFlags().String("tendermint-home", os.ExpandEnv(filepath.Join("$HOME", tmcfg.DefaultTendermintDir)),
+		"Tendermint home directory")
+	ClientCmd.PersistentFlags().String("remote", "http://localhost:27001", "Address of the director")
+	ClientCmd.PersistentFlags().String("chain-id", "", "Chain ID of the testnet")
+	ClientCmd.AddCommand(ClientRegisterCmd, ClientFetchCmd)
+}
+
+// clientFlags returns the shared flags of the client commands: the Tendermint config, the director client and the chain ID.
+// The flags are read from viper to apply the DIRECTOR_* environment variables.
+func clientFlags(cmd *cobra.Command) (*tmcfg.Config, *client.Client, string, error) {
+	home := viper.GetString("tendermint-home")
+	remote := viper.GetString("remote")
+	chainID := viper.GetString("chain-id")
+	if chainID == "" {
+		return nil, nil, "", errors.New("--chain-id is required")
+	}
+
+	tmConfig, err := parseTendermintConfig(home)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	c, err := client.New(remote)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return tmConfig, c, chainID, nil
+}
+
+// parseTendermintConfig reads the config.toml of a Tendermint home. The defaults are used if there is none.
+func parseTendermintConfig(home string) (*tmcfg.Config, error) {
+	conf := tmcfg.DefaultConfig()
+	path := filepath.Join(home, "config", "config.toml")
+	if tmos.FileExists(path) {
+		v := viper.New()
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+		if err := v.Unmarshal(conf); err != nil {
+			return nil, fmt.Errorf("error in %s: %v", path, err)
+		}
+	}
+	conf.SetRoot(home)
+	return conf, nil
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	tmos "github.com/tendermint/tendermint/libs/os"
+	"github.com/tendermint/tendermint/libs/tempfile"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// ClientFetchCmd waits for the genesis of a testnet and writes genesis.json and addrbook.json
+// into the Tendermint config directory
+var ClientFetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Wait for the genesis of a testnet and write genesis.json and addrbook.json",
+	Long: `Wait for the genesis of a testnet and write genesis.json and addrbook.json to the paths set in config.toml.
+The downloads are checked against the checksums published by the director. Existing files with a different
+checksum are kept unless --overwrite is set. This includes the single-validator genesis.json written by
+tendermint init, so the first fetch after it needs --overwrite.`,
+	RunE: clientFetch,
+}
+
+func init() {
+	ClientFetchCmd.Flags().Duration("timeout", 0, "Maximum time to wait for the genesis (default: no limit)")
+	ClientFetchCmd.Flags().Bool("overwrite", false, "Replace existing files that differ from the downloads")
+}
+
+func clientFetch(cmd *cobra.Command, args []string) error {
+	tmConfig, c, chainID, err := clientFlags(cmd)
+	if err != nil {
+		return err
+	}
+	timeout := viper.GetDuration("timeout")
+	overwrite := viper.GetBool("overwrite")
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	logger.Info("Waiting for the genesis", "chain_id", chainID)
+	if _, err := c.WaitForGenesis(ctx, chainID); err != nil {
+		return fmt.Errorf("failed to wait for the genesis: %v", err)
+	}
+
+	// Download and check every file before writing any of them
+	files := []struct {
+		name string
+		path string
+	}{
+		{"genesis.json", tmConfig.GenesisFile()},
+		{"addrbook.json", tmConfig.P2P.AddrBookFile()},
+	}
+	contents := make([][]byte, len(files))
+	for i, file := range files {
+		if contents[i], err = c.File(ctx, chainID, file.name); err != nil {
+			return err
+		}
+		if !overwrite && tmos.FileExists(file.path) {
+			existing, err := ioutil.ReadFile(file.path)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(existing, contents[i]) {
+				return fmt.Errorf("%s exists with checksum %x, the director has %x: use --overwrite to replace it, "+
+					"for example the genesis written by tendermint init", file.path, sha256.Sum256(existing), sha256.Sum256(contents[i]))
+			}
+		}
+	}
+
+	for i, file := range files {
+		if err := tmos.EnsureDir(filepath.Dir(file.path), 0700); err != nil {
+			return err
+		}
+		if err := tempfile.WriteFileAtomic(file.path, contents[i], 0644); err != nil {
+			return err
+		}
+		logger.Info("Wrote "+file.name, "path", file.path, "sha256", fmt.Sprintf("%x", sha256.Sum256(contents[i])))
+	}
+	return nil
+}
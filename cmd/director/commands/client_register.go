@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"context"
+	"director/m/v2/client"
+	"director/m/v2/rpc/core"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tendermint/go-amino"
+	cryptoamino "github.com/tendermint/tendermint/crypto/encoding/amino"
+	"github.com/tendermint/tendermint/p2p"
+	"github.com/tendermint/tendermint/privval"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ClientRegisterCmd registers the local Tendermint node with a director.
+// Only the public keys of priv_validator_key.json and node_key.json are sent.
+var ClientRegisterCmd = &cobra.Command{
+	Use:   "register",
+	Short: "Register the local Tendermint node with a director",
+	Long: `Register the local Tendermint node with a director.
+The public keys are read from priv_validator_key.json and node_key.json in the Tendermint home.
+The moniker and the address default to the moniker and p2p.external_address of its config.toml.`,
+	RunE: clientRegister,
+}
+
+func init() {
+	ClientRegisterCmd.Flags().String("name", "", "Name of the node (default: the moniker of config.toml)")
+	ClientRegisterCmd.Flags().String("host", "", "Public host of the node (default: the host of p2p.external_address)")
+	ClientRegisterCmd.Flags().Uint16("port", 0, "P2P port of the node (default: the port of p2p.external_address or p2p.laddr)")
+	ClientRegisterCmd.Flags().String("role", "", "Role of the node: validator (default), sentry, seed or full")
+	ClientRegisterCmd.Flags().Bool("seed", false, "Flag the node as a seed")
+	ClientRegisterCmd.Flags().Int64("power", 0, "Genesis voting power (default: the default of the testnet)")
+	ClientRegisterCmd.Flags().String("account-address", "", "Account address for Cosmos-SDK testnets")
+	ClientRegisterCmd.Flags().String("gentx", "", "File with a signed gentx for Cosmos-SDK testnets")
+	ClientRegisterCmd.Flags().StringSlice("sentries", nil, "Node IDs of the sentries of a private validator")
+	ClientRegisterCmd.Flags().Bool("private", false, "Hide the address of the node behind its sentries")
+}
+
+func clientRegister(cmd *cobra.Command, args []string) error {
+	tmConfig, c, chainID, err := clientFlags(cmd)
+	if err != nil {
+		return err
+	}
+	name := viper.GetString("name")
+	host := viper.GetString("host")
+	port := uint16(viper.GetUint("port"))
+	role := viper.GetString("role")
+	seed := viper.GetBool("seed")
+	power := viper.GetInt64("power")
+	accountAddress := viper.GetString("account-address")
+	genTxFile := viper.GetString("gentx")
+	sentries := viper.GetStringSlice("sentries")
+	private := viper.GetBool("private")
+
+	if name == "" {
+		name = tmConfig.Moniker
+	}
+	if host == "" || port == 0 {
+		defaultHost, defaultPort, err := p2pAddress(tmConfig.P2P.ExternalAddress, tmConfig.P2P.ListenAddress)
+		if err != nil {
+			return err
+		}
+		if host == "" {
+			host = defaultHost
+		}
+		if port == 0 {
+			port = defaultPort
+		}
+	}
+	if host == "" {
+		return errors.New("--host is required if p2p.external_address is not set in config.toml")
+	}
+	var genTx []byte
+	if genTxFile != "" {
+		if genTx, err = ioutil.ReadFile(genTxFile); err != nil {
+			return err
+		}
+	}
+
+	privValidatorKey, err := loadPrivValidatorKey(tmConfig.PrivValidatorKeyFile())
+	if err != nil {
+		return err
+	}
+	nodeKey, err := p2p.LoadNodeKey(tmConfig.NodeKeyFile())
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", tmConfig.NodeKeyFile(), err)
+	}
+
+	result, err := c.RegisterJSON(context.Background(), chainID,
+		core.PrivValidatorKeyJSON{Address: privValidatorKey.Address, PubKey: privValidatorKey.PubKey},
+		core.NodeKeyJSON{ID: nodeKey.ID(), PubKey: nodeKey.PubKey()},
+		host, port,
+		client.Registration{
+			Name:           name,
+			Seed:           seed,
+			AccountAddress: accountAddress,
+			GenTx:          genTx,
+			Power:          power,
+			Role:           role,
+			Sentries:       sentries,
+			Private:        private,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to register: %v", err)
+	}
+	logger.Info("Registered", "chain_id", result.ChainID, "node_id", result.NodeID, "role", result.Role)
+	return nil
+}
+
+// loadPrivValidatorKey reads priv_validator_key.json
+func loadPrivValidatorKey(path string) (*privval.FilePVKey, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cdc := amino.NewCodec()
+	cryptoamino.RegisterAmino(cdc)
+	key := &privval.FilePVKey{}
+	if err := cdc.UnmarshalJSON(content, key); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	return key, nil
+}
+
+// p2pAddress returns the host of the external address and the port of the external or the listen address
+func p2pAddress(externalAddress string, listenAddress string) (string, uint16, error) {
+	var host string
+	address := listenAddress
+	if externalAddress != "" {
+		address = externalAddress
+	}
+	if i := strings.Index(address, "://"); i >= 0 {
+		address = address[i+3:]
+	}
+	h, p, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid p2p address %s: %v", address, err)
+	}
+	if externalAddress != "" {
+		host = h
+	}
+	port, err := strconv.ParseUint(p, 10, 16)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid p2p address %s: %v", address, err)
+	}
+	return host, uint16(port), nil
+}
@@ -0,0 +1,166 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/cli"
+	"github.com/tendermint/tendermint/libs/log"
+	tmos "github.com/tendermint/tendermint/libs/os"
+)
+
+func TestP2PAddress(t *testing.T) {
+	testCases := []struct {
+		externalAddress string
+		listenAddress   string
+		host            string
+		port            uint16
+	}{
+		{"", "tcp://0.0.0.0:26656", "", 26656},
+		{"", "0.0.0.0:26000", "", 26000},
+		{"203.0.113.1:26656", "tcp://0.0.0.0:26000", "203.0.113.1", 26656},
+		{"tcp://node.example.com:26000", "tcp://0.0.0.0:26656", "node.example.com", 26000},
+		{"[2001:db8::1]:26656", "tcp://0.0.0.0:26656", "2001:db8::1", 26656},
+	}
+	for _, tc := range testCases {
+		host, port, err := p2pAddress(tc.externalAddress, tc.listenAddress)
+		require.NoError(t, err, tc.externalAddress)
+		assert.Equal(t, tc.host, host, tc.externalAddress)
+		assert.Equal(t, tc.port, port, tc.externalAddress)
+	}
+
+	for _, address := range []string{"203.0.113.1", "203.0.113.1:port", "203.0.113.1:65536", "tcp://"} {
+		_, _, err := p2pAddress(address, "tcp://0.0.0.0:26656")
+		assert.Error(t, err, address)
+	}
+}
+
+// testDirector serves the genesis and the files of the "default" testnet like a director
+type testDirector struct {
+	files     map[string]string
+	checksums map[string]string
+}
+
+func newTestDirector() *testDirector {
+	d := &testDirector{
+		files: map[string]string{
+			"genesis.json":  `{"chain_id": "default"}`,
+			"addrbook.json": `{"key": "", "addrs": []}`,
+		},
+		checksums: map[string]string{},
+	}
+	for name, content := range d.files {
+		d.checksums[name] = fmt.Sprintf("%x", sha256.Sum256([]byte(content)))
+	}
+	return d
+}
+
+func (d *testDirector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		fmt.Fprint(w, `{"jsonrpc": "2.0", "id": 1, "result": {"genesis": {"chain_id": "default"}}}`)
+		return
+	}
+	for name, content := range d.files {
+		switch r.URL.Path {
+		case "/files/default/" + name:
+			fmt.Fprint(w, content)
+			return
+		case "/files/default/" + name + ".sha256":
+			fmt.Fprintf(w, "%s  %s\n", d.checksums[name], name)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+var prepareRootCmd sync.Once
+
+// runCommand executes the root command with the arguments and resets the flags and viper afterwards
+func runCommand(args ...string) error {
+	prepareRootCmd.Do(func() {
+		cli.PrepareBaseCmd(RootCmd, "DIRECTOR", os.ExpandEnv(filepath.Join("$HOME", ".director-test")))
+		RootCmd.AddCommand(ClientCmd)
+		RootCmd.SilenceUsage = true
+		RootCmd.SilenceErrors = true
+	})
+	defer func() {
+		viper.Reset()
+		for _, flags := range []*pflag.FlagSet{RootCmd.PersistentFlags(), ClientCmd.PersistentFlags(), ClientFetchCmd.Flags()} {
+			flags.VisitAll(func(flag *pflag.Flag) {
+				flag.Value.Set(flag.DefValue) // nolint: errcheck
+				flag.Changed = false
+			})
+		}
+	}()
+	logger = log.NewNopLogger()
+	RootCmd.SetArgs(args)
+	return RootCmd.Execute()
+}
+
+func TestClientFetch(t *testing.T) {
+	director := newTestDirector()
+	server := httptest.NewServer(director)
+	defer server.Close()
+	home, err := ioutil.TempDir("", "director-client")
+	require.NoError(t, err)
+	defer os.RemoveAll(home) // nolint: errcheck
+	genesisFile := filepath.Join(home, "config", "genesis.json")
+	addrBookFile := filepath.Join(home, "config", "addrbook.json")
+	fetch := func(args ...string) error {
+		return runCommand(append([]string{"client", "fetch", "--tendermint-home", home, "--remote", server.URL}, args...)...)
+	}
+
+	assert.EqualError(t, fetch(), "--chain-id is required")
+
+	require.NoError(t, fetch("--chain-id", "default"))
+	for path, content := range map[string]string{genesisFile: director.files["genesis.json"], addrBookFile: director.files["addrbook.json"]} {
+		written, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(written))
+	}
+
+	// The chain ID can be set in the environment like the flags of the other commands
+	require.NoError(t, os.Setenv("DIRECTOR_CHAIN_ID", "default"))
+	err = fetch()
+	require.NoError(t, os.Unsetenv("DIRECTOR_CHAIN_ID"))
+	assert.NoError(t, err)
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		director.files["addrbook.json"] = `{"key": "changed", "addrs": []}`
+		defer func() { director.files["addrbook.json"] = `{"key": "", "addrs": []}` }()
+		err := fetch("--chain-id", "default", "--overwrite")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch for addrbook.json of default")
+	})
+
+	t.Run("existing file", func(t *testing.T) {
+		// A genesis written by tendermint init
+		require.NoError(t, ioutil.WriteFile(genesisFile, []byte(`{"chain_id": "test-chain-abc"}`), 0644))
+		require.NoError(t, os.Remove(addrBookFile))
+		err := fetch("--chain-id", "default")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), genesisFile+" exists with checksum")
+		assert.Contains(t, err.Error(), "use --overwrite to replace it")
+		// No file is written if one of them differs
+		assert.False(t, tmos.FileExists(addrBookFile))
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		require.NoError(t, fetch("--chain-id", "default", "--overwrite"))
+		written, err := ioutil.ReadFile(genesisFile)
+		require.NoError(t, err)
+		assert.Equal(t, director.files["genesis.json"], string(written))
+		assert.True(t, tmos.FileExists(addrBookFile))
+	})
+}
@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"github.com/spf13/cobra"
+	"path/filepath"
+)
+
+// InitFilesCmd initialises a fresh Director instance.
+var InitFilesCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Initialize Director",
+	RunE:  initFiles,
+}
+
+// initFiles only reports the config file: ParseConfig already wrote the default one if it was missing.
+func initFiles(cmd *cobra.Command, args []string) error {
+	logger.Info("Config file", "path", filepath.Join(config.RootDir, "config", "config.toml"))
+	return nil
+}
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	nm "director/m/v2/node"
+	"director/m/v2/store"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// RestoreCmd replaces the testnet store with a snapshot. The node must be stopped.
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Replace the testnet store with a snapshot",
+	Long: `Replace the testnet store with a snapshot. The node must be stopped.
+--at takes a snapshot file, the name of a snapshot in the snapshot directory,
+or an RFC3339 time to restore the latest snapshot taken at or before that time.`,
+	RunE: restore,
+}
+
+func init() {
+	RestoreCmd.Flags().String("at", "", "Snapshot file, snapshot name or RFC3339 time")
+}
+
+func restore(cmd *cobra.Command, args []string) error {
+	at, err := cmd.Flags().GetString("at")
+	if err != nil {
+		return err
+	}
+	if at == "" {
+		return errors.New("--at is required")
+	}
+	path, err := store.FindSnapshot(config.Snapshots.SnapshotDir(), at)
+	if err != nil {
+		return err
+	}
+	snapshot, err := store.ReadSnapshot(path)
+	if err != nil {
+		return err
+	}
+
+	keySpace, closer, err := nm.OpenKeySpace(config, nm.DefaultDBProvider)
+	if err != nil {
+		return err
+	}
+	defer closer() // nolint: errcheck
+
+	if err := store.RestoreSnapshot(keySpace, snapshot); err != nil {
+		return fmt.Errorf("failed to restore snapshot %s: %v", path, err)
+	}
+	logger.Info("Snapshot restored", "path", path, "time", snapshot.Time, "testnets", len(snapshot.Entries))
+	return nil
+}
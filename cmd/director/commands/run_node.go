@@ -0,0 +1,38 @@
+package commands
+
+import (
+	nm "director/m/v2/node"
+	"fmt"
+	"github.com/spf13/cobra"
+	tmos "github.com/tendermint/tendermint/libs/os"
+)
+
+// NewRunNodeCmd returns the command that allows the CLI to start a node.
+func NewRunNodeCmd(nodeProvider nm.Provider) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "node",
+		Short: "Run the director node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			n, err := nodeProvider(config, logger)
+			if err != nil {
+				return fmt.Errorf("failed to create node: %v", err)
+			}
+
+			// Stop upon receiving SIGTERM or CTRL-C.
+			tmos.TrapSignal(logger, func() {
+				if n.IsRunning() {
+					_ = n.Stop()
+				}
+			})
+
+			if err := n.Start(); err != nil {
+				return fmt.Errorf("failed to start node: %v", err)
+			}
+			logger.Info("Started node", "laddr", config.RPC.ListenAddress)
+
+			// Run forever.
+			select {}
+		},
+	}
+	return cmd
+}
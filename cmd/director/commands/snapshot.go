@@ -0,0 +1,31 @@
+package commands
+
+import (
+	nm "director/m/v2/node"
+	"director/m/v2/store"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// SnapshotCmd writes a snapshot of the testnet store while the node is stopped.
+// A running node takes snapshots with the snapshot endpoint.
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Write a snapshot of the testnet store to the snapshot directory",
+	RunE:  snapshot,
+}
+
+func snapshot(cmd *cobra.Command, args []string) error {
+	keySpace, closer, err := nm.OpenKeySpace(config, nm.DefaultDBProvider)
+	if err != nil {
+		return err
+	}
+	defer closer() // nolint: errcheck
+
+	path, err := store.WriteSnapshot(keySpace, config.Snapshots.SnapshotDir(), config.Snapshots.Retention)
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %v", err)
+	}
+	logger.Info("Snapshot taken", "path", path)
+	return nil
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	cfg "director/m/v2/config"
+	nm "director/m/v2/node"
+	"director/m/v2/store"
+	"director/m/v2/types"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+)
+
+// setupTestConfig makes the commands use a new home directory with the given db_backend
+func setupTestConfig(t *testing.T, dbBackend string) func() {
+	home, err := ioutil.TempDir("", "director-commands")
+	require.NoError(t, err)
+	config = cfg.DefaultConfig().SetRoot(home)
+	config.DBBackend = dbBackend
+	*config.Testnets = map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 2}}
+	logger = log.NewNopLogger()
+	return func() { os.RemoveAll(home) } // nolint: errcheck
+}
+
+// openTestStore opens the testnet store of the config like the node does, and returns a function releasing it
+func openTestStore(t *testing.T) (*store.TestnetDB, func()) {
+	if config.DBBackend == cfg.DBBackendJSON {
+		return store.NewJSONStore(filepath.Join(config.DBDir(), "testnets"), *config.Testnets), func() {}
+	}
+	db, err := nm.DefaultDBProvider(&nm.DBContext{ID: "testnetDB", Config: config})
+	require.NoError(t, err)
+	return store.NewStore(db, *config.Testnets), func() { db.Close() } // nolint: errcheck
+}
+
+// registerTestValidator registers a validator with new keys while the node is stopped
+func registerTestValidator(t *testing.T, name string) {
+	s, closer := openTestStore(t)
+	defer closer()
+	_, pubKey, err := types.PubKeyToBase64(ed25519.GenPrivKey().PubKey())
+	require.NoError(t, err)
+	nodeID := p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())
+	address, err := types.NewNetAddressString(p2p.IDAddressString(nodeID, net.JoinHostPort("127.0.0.1", "26656")), types.ResolverFunc(net.LookupIP))
+	require.NoError(t, err)
+	require.NoError(t, s.RegisterValidator("default", store.ValidatorConfig{Name: name, PubKey: pubKey, NetAddress: address}))
+}
+
+func testnetStatus(t *testing.T) *store.TestnetStatus {
+	s, closer := openTestStore(t)
+	defer closer()
+	status, err := s.GetStatus("default")
+	require.NoError(t, err)
+	return status
+}
+
+// restore --at rolls the database back to a snapshot taken by the snapshot command
+func TestSnapshotAndRestore(t *testing.T) {
+	for _, dbBackend := range []string{"goleveldb", cfg.DBBackendJSON} {
+		t.Run(dbBackend, func(t *testing.T) {
+			defer setupTestConfig(t, dbBackend)()
+			registerTestValidator(t, "validator1")
+			require.NoError(t, snapshot(SnapshotCmd, nil))
+			snapshots, err := store.ListSnapshots(config.Snapshots.SnapshotDir())
+			require.NoError(t, err)
+			require.Len(t, snapshots, 1)
+
+			registerTestValidator(t, "validator2")
+			require.Equal(t, types.Serve, testnetStatus(t).State)
+
+			require.NoError(t, RestoreCmd.Flags().Set("at", time.Now().UTC().Add(time.Second).Format(time.RFC3339)))
+			defer RestoreCmd.Flags().Set("at", "") // nolint: errcheck
+			require.NoError(t, restore(RestoreCmd, nil))
+			status := testnetStatus(t)
+			assert.Equal(t, types.Gather, status.State)
+			assert.Equal(t, 1, status.Validators)
+
+			// The registrations continue from the snapshot
+			registerTestValidator(t, "validator3")
+			assert.Equal(t, types.Serve, testnetStatus(t).State)
+		})
+	}
+}
+
+func TestRestoreErrors(t *testing.T) {
+	defer setupTestConfig(t, cfg.DBBackendJSON)()
+	assert.EqualError(t, restore(RestoreCmd, nil), "--at is required")
+
+	require.NoError(t, RestoreCmd.Flags().Set("at", "2020-01-01T00:00:00Z"))
+	defer RestoreCmd.Flags().Set("at", "") // nolint: errcheck
+	assert.Error(t, restore(RestoreCmd, nil))
+
+	config.DBBackend = "memdb"
+	assert.Error(t, snapshot(SnapshotCmd, nil))
+}
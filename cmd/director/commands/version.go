@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"director/m/v2/version"
+	"fmt"
+	"github.com/spf13/cobra"
+)
+
+// VersionCmd prints the version of Director
+var VersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show version info",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(version.Version)
+	},
+}
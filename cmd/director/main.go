@@ -0,0 +1,27 @@
+package main
+
+import (
+	cmd "director/m/v2/cmd/director/commands"
+	cfg "director/m/v2/config"
+	nm "director/m/v2/node"
+	"github.com/tendermint/tendermint/libs/cli"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	rootCmd := cmd.RootCmd
+	rootCmd.AddCommand(
+		cmd.InitFilesCmd,
+		cmd.NewRunNodeCmd(nm.DefaultNewNode),
+		cmd.SnapshotCmd,
+		cmd.RestoreCmd,
+		cmd.ClientCmd,
+		cmd.VersionCmd,
+	)
+
+	executor := cli.PrepareBaseCmd(rootCmd, "DIRECTOR", os.ExpandEnv(filepath.Join("$HOME", cfg.DefaultDirectorDir)))
+	if err := executor.Execute(); err != nil {
+		panic(err)
+	}
+}
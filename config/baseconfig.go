@@ -3,8 +3,12 @@ package config
 import (
 	"errors"
 	"fmt"
+	dbm "github.com/tendermint/tm-db"
 )
 
+// DBBackendJSON is the db_backend that keeps every testnet in a plain JSON file
+const DBBackendJSON = "json"
+
 //-----------------------------------------------------------------------------
 // BaseConfig
 
@@ -14,7 +18,7 @@ type BaseConfig struct { //nolint: maligned
 	// This should be set in viper so it can unmarshal into this struct
 	RootDir string `mapstructure:"home"`
 
-	// Database backend: goleveldb | cleveldb | boltdb
+	// Database backend: goleveldb | cleveldb | boltdb | memdb | json
 	// * goleveldb (github.com/syndtr/goleveldb - most popular implementation)
 	//   - pure go
 	//   - stable
@@ -26,6 +30,12 @@ type BaseConfig struct { //nolint: maligned
 	//   - EXPERIMENTAL
 	//   - may be faster is some use-cases (random reads - indexer)
 	//   - use boltdb build tag (go build -tags boltdb)
+	// * memdb
+	//   - keeps everything in memory, nothing survives a restart
+	//   - for tests and throwaway testnets
+	// * json (one plain JSON file per testnet in db_dir/testnets)
+	//   - easy to inspect by hand
+	//   - for lightweight deployments
 	DBBackend string `mapstructure:"db_backend"`
 
 	// Database directory
@@ -61,6 +71,11 @@ func (cfg BaseConfig) ValidateBasic() error {
 	default:
 		return errors.New("unknown log_format (must be 'plain' or 'json')")
 	}
+	switch dbm.BackendType(cfg.DBBackend) {
+	case dbm.GoLevelDBBackend, dbm.CLevelDBBackend, dbm.BoltDBBackend, dbm.RocksDBBackend, dbm.MemDBBackend, DBBackendJSON:
+	default:
+		return fmt.Errorf("unknown db_backend %s (must be goleveldb, cleveldb, boltdb, rocksdb, memdb or json)", cfg.DBBackend)
+	}
 	return nil
 }
 
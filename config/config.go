@@ -1,9 +1,18 @@
 package config
 
 import (
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	tmcfg "github.com/tendermint/tendermint/config"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"net/url"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +21,31 @@ const (
 	LogFormatPlain = "plain"
 	// LogFormatJSON is a format for json output
 	LogFormatJSON = "json"
+
+	// TimeoutPolicyCompile compiles the genesis with the registrations at hand when the timeout passes
+	TimeoutPolicyCompile = "compile"
+	// TimeoutPolicyExtend extends the registration period by timeout_extension
+	TimeoutPolicyExtend = "extend"
+	// TimeoutPolicyFail marks the testnet failed
+	TimeoutPolicyFail = "fail"
+
+	// SelectionPolicyFirstCome picks the genesis validators in registration order
+	SelectionPolicyFirstCome = "first-come"
+	// SelectionPolicyHighestPower picks the genesis validators with the highest voting power
+	SelectionPolicyHighestPower = "highest-power"
+	// SelectionPolicyAdmin picks the genesis validators selected by an admin
+	SelectionPolicyAdmin = "admin"
+
+	// ElectorLockFile elects the director holding a lock on a shared file
+	ElectorLockFile = "lockfile"
+
+	// FollowerWritesProxy forwards the writes a follower receives to the leader
+	FollowerWritesProxy = "proxy"
+	// FollowerWritesReject rejects the writes a follower receives
+	FollowerWritesReject = "reject"
+
+	// instancePlaceholder is replaced by the instance number in the chain_id_pattern of recurring testnets
+	instancePlaceholder = "{n}"
 )
 
 var (
@@ -35,13 +69,22 @@ type Config struct {
 	BaseConfig `mapstructure:",squash"`
 
 	// Options for services
-	RPC *tmcfg.RPCConfig `mapstructure:"rpc"`
+	RPC *RPCConfig `mapstructure:"rpc"`
 
 	// Testnet descriptions
 	Testnets *map[string]TestnetsTOMLConfig `mapstructure:"testnets"`
 
+	// Testnet templates. Testnets refer to them with the template option, see LoadConfig.
+	Templates *map[string]TestnetsTOMLConfig `mapstructure:"templates"`
+
 	// State machine heartbeat
 	StateMachineHeartbeat *time.Duration `mapstructure:"statemachine_heartbeat"`
+
+	// Options for store snapshots
+	Snapshots *SnapshotsConfig `mapstructure:"snapshots"`
+
+	// Options for running several directors with leader election
+	HA *HAConfig `mapstructure:"ha"`
 }
 
 // DefaultConfig returns a default configuration struct
@@ -50,7 +93,10 @@ func DefaultConfig() *Config {
 		BaseConfig:            DefaultBaseConfig(),
 		RPC:                   DefaultRPCConfig(),
 		Testnets:              DefaultTestnetsTOMLConfig(),
+		Templates:             DefaultTestnetsTOMLConfig(),
 		StateMachineHeartbeat: defaultStateMachineHeartbeat(),
+		Snapshots:             DefaultSnapshotsConfig(),
+		HA:                    DefaultHAConfig(),
 	}
 }
 
@@ -58,6 +104,8 @@ func DefaultConfig() *Config {
 func (cfg *Config) SetRoot(root string) *Config {
 	cfg.BaseConfig.RootDir = root
 	cfg.RPC.RootDir = root
+	cfg.Snapshots.RootDir = root
+	cfg.HA.RootDir = root
 	for _, testnet := range *cfg.Testnets {
 		testnet.RootDir = root
 	}
@@ -73,17 +121,120 @@ func (cfg *Config) ValidateBasic() error {
 	if err := cfg.RPC.ValidateBasic(); err != nil {
 		return errors.Wrap(err, "error in [rpc] section")
 	}
-	for _, testnet := range *cfg.Testnets {
+	for chainID, testnet := range *cfg.Testnets {
+		if testnet.Template != "" {
+			if _, ok := (*cfg.Templates)[testnet.Template]; !ok {
+				return errors.Errorf("error in [testnets.%s]: unknown template %s", chainID, testnet.Template)
+			}
+		}
 		if err := testnet.ValidateBasic(); err != nil {
-			return err
+			if testnet.Template != "" {
+				return errors.Wrapf(err, "error in [testnets.%s] using [templates.%s]", chainID, testnet.Template)
+			}
+			return errors.Wrapf(err, "error in [testnets.%s]", chainID)
 		}
 	}
+	if err := cfg.validateChainIDs(); err != nil {
+		return err
+	}
+	if err := cfg.Snapshots.ValidateBasic(); err != nil {
+		return errors.Wrap(err, "error in [snapshots] section")
+	}
+	if err := cfg.HA.ValidateBasic(); err != nil {
+		return errors.Wrap(err, "error in [ha] section")
+	}
+	if cfg.HA.IsEnabled() && cfg.DBBackend != DBBackendJSON {
+		return errors.Errorf("error in [ha] section: the directors must share the store, use db_backend = %q", DBBackendJSON)
+	}
 	if *cfg.StateMachineHeartbeat <= 0 {
 		return errors.New("no heartbeat set")
 	}
 	return nil
 }
 
+// validateChainIDs checks that the instances of recurring testnets can't take the chain ID of another testnet
+func (cfg *Config) validateChainIDs() error {
+	keys := make([]string, 0, len(*cfg.Testnets))
+	for key := range *cfg.Testnets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		testnet := (*cfg.Testnets)[key]
+		if !testnet.IsRecurring() {
+			continue
+		}
+		for _, other := range keys {
+			otherTestnet := (*cfg.Testnets)[other]
+			if other == key {
+				continue
+			}
+			if otherTestnet.IsRecurring() && otherTestnet.ChainIDPattern == testnet.ChainIDPattern {
+				return errors.Errorf("error in [testnets.%s]: chain_id_pattern %s is also used by [testnets.%s]", key, testnet.ChainIDPattern, other)
+			}
+			if !otherTestnet.IsRecurring() && testnet.IsInstanceChainID(other) {
+				return errors.Errorf("error in [testnets.%s]: chain_id_pattern %s matches the chain ID of [testnets.%s]", key, testnet.ChainIDPattern, other)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadConfig decodes the raw settings of a config file, for example viper.AllSettings(), over the default config
+// and applies the testnet templates. Programs reading the config should use it instead of decoding it themselves,
+// which would leave the testnets that use a template without the options of the template.
+func LoadConfig(settings map[string]interface{}) (*Config, error) {
+	cfg := DefaultConfig()
+	if err := decode(settings, cfg, false); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveTemplates(settings); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// resolveTemplates applies the [templates.<name>] sections to the testnets that set `template = "<name>"`.
+// Every key set in a testnet section overrides the same key of its template, even when set to a zero value,
+// so the raw settings the config was read from are needed.
+func (cfg *Config) resolveTemplates(settings map[string]interface{}) error {
+	rawTemplates, err := toStringMap(settings["templates"])
+	if err != nil {
+		return errors.Wrap(err, "error in [templates] section")
+	}
+	rawTestnets, err := toStringMap(settings["testnets"])
+	if err != nil {
+		return errors.Wrap(err, "error in [testnets] section")
+	}
+
+	for chainID, rawTestnet := range rawTestnets {
+		testnet, err := toStringMap(rawTestnet)
+		if err != nil {
+			return errors.Wrapf(err, "error in [testnets.%s]", chainID)
+		}
+		name, ok := testnet["template"].(string)
+		if !ok || name == "" {
+			continue
+		}
+		rawTemplate, ok := rawTemplates[name]
+		if !ok {
+			return errors.Errorf("error in [testnets.%s]: unknown template %s", chainID, name)
+		}
+		template, err := toStringMap(rawTemplate)
+		if err != nil {
+			return errors.Wrapf(err, "error in [templates.%s]", name)
+		}
+
+		var resolved TestnetsTOMLConfig
+		if err := decode(mergeStringMaps(template, testnet), &resolved, false); err != nil {
+			return errors.Wrapf(err, "error in [testnets.%s] using [templates.%s]", chainID, name)
+		}
+		resolved.RootDir = cfg.RootDir
+		(*cfg.Testnets)[chainID] = resolved
+	}
+	return nil
+}
+
 func defaultStateMachineHeartbeat() *time.Duration {
 	return &StateMachineHeartbeat
 }
@@ -91,11 +242,203 @@ func defaultStateMachineHeartbeat() *time.Duration {
 //-----------------------------------------------------------------------------
 // RPCConfig
 
+// RPCConfig defines the configuration options for the Director RPC server
+type RPCConfig struct {
+	tmcfg.RPCConfig `mapstructure:",squash"`
+
+	// Sustained number of requests per second accepted from a single remote IP.
+	// 0 - unlimited.
+	RateLimitPerIP float64 `mapstructure:"rate_limit_per_ip"`
+
+	// Number of requests a single remote IP can make in a burst above RateLimitPerIP.
+	RateLimitPerIPBurst int `mapstructure:"rate_limit_per_ip_burst"`
+
+	// Sustained number of registrations per second accepted for a single chain ID.
+	// 0 - unlimited.
+	RateLimitPerChain float64 `mapstructure:"rate_limit_per_chain"`
+
+	// Number of registrations a single chain ID accepts in a burst above RateLimitPerChain.
+	RateLimitPerChainBurst int `mapstructure:"rate_limit_per_chain_burst"`
+
+	// Number of throttled requests after which a remote IP is temporarily banned.
+	// 0 - never ban.
+	BanThreshold int `mapstructure:"ban_threshold"`
+
+	// How long a remote IP stays banned.
+	BanDuration time.Duration `mapstructure:"ban_duration"`
+}
+
 // DefaultRPCConfig returns a default configuration for the RPC server
-func DefaultRPCConfig() *tmcfg.RPCConfig {
+func DefaultRPCConfig() *RPCConfig {
 	result := tmcfg.DefaultRPCConfig()
 	result.ListenAddress = defaultListenAddress
-	return result
+	return &RPCConfig{
+		RPCConfig:              *result,
+		RateLimitPerIP:         10,
+		RateLimitPerIPBurst:    20,
+		RateLimitPerChain:      5,
+		RateLimitPerChainBurst: 10,
+		BanThreshold:           100,
+		BanDuration:            10 * time.Minute,
+	}
+}
+
+// ValidateBasic performs basic validation (checking param bounds, etc.) and
+// returns an error if any check fails.
+func (cfg *RPCConfig) ValidateBasic() error {
+	if err := cfg.RPCConfig.ValidateBasic(); err != nil {
+		return err
+	}
+	if cfg.RateLimitPerIP < 0 {
+		return errors.New("rate_limit_per_ip can't be negative")
+	}
+	if cfg.RateLimitPerIP > 0 && cfg.RateLimitPerIPBurst < 1 {
+		return errors.New("rate_limit_per_ip_burst must be at least 1 when rate_limit_per_ip is set")
+	}
+	if cfg.RateLimitPerChain < 0 {
+		return errors.New("rate_limit_per_chain can't be negative")
+	}
+	if cfg.RateLimitPerChain > 0 && cfg.RateLimitPerChainBurst < 1 {
+		return errors.New("rate_limit_per_chain_burst must be at least 1 when rate_limit_per_chain is set")
+	}
+	if cfg.BanThreshold < 0 {
+		return errors.New("ban_threshold can't be negative")
+	}
+	if cfg.BanThreshold > 0 && cfg.BanDuration <= 0 {
+		return errors.New("ban_duration must be positive when ban_threshold is set")
+	}
+	return nil
+}
+
+// IsRateLimitEnabled returns true if any of the rate limits are set.
+func (cfg *RPCConfig) IsRateLimitEnabled() bool {
+	return cfg.RateLimitPerIP > 0 || cfg.RateLimitPerChain > 0
+}
+
+//-----------------------------------------------------------------------------
+// SnapshotsConfig
+
+// SnapshotsConfig defines the configuration options for store snapshots
+type SnapshotsConfig struct {
+	RootDir string `mapstructure:"home"`
+
+	// Directory of the snapshot files
+	Dir string `mapstructure:"dir"`
+
+	// Time between periodic snapshots.
+	// 0 - no periodic snapshots.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Number of snapshots kept, older ones are deleted.
+	// 0 - keep all.
+	Retention int `mapstructure:"retention"`
+}
+
+// DefaultSnapshotsConfig returns a default configuration for store snapshots
+func DefaultSnapshotsConfig() *SnapshotsConfig {
+	return &SnapshotsConfig{
+		Dir:       filepath.Join(defaultDataDir, "snapshots"),
+		Interval:  0,
+		Retention: 10,
+	}
+}
+
+// ValidateBasic performs basic validation (checking param bounds, etc.) and
+// returns an error if any check fails.
+func (cfg *SnapshotsConfig) ValidateBasic() error {
+	if cfg.Dir == "" {
+		return errors.New("dir can't be empty")
+	}
+	if cfg.Interval < 0 {
+		return errors.New("interval can't be negative")
+	}
+	if cfg.Retention < 0 {
+		return errors.New("retention can't be negative")
+	}
+	return nil
+}
+
+// SnapshotDir returns the full path to the snapshot directory
+func (cfg *SnapshotsConfig) SnapshotDir() string {
+	return rootify(cfg.Dir, cfg.RootDir)
+}
+
+//-----------------------------------------------------------------------------
+// HAConfig
+
+// HAConfig defines the configuration options for running several directors on a shared store.
+// One director is elected leader and runs the state machine, the others follow.
+type HAConfig struct {
+	RootDir string `mapstructure:"home"`
+
+	// Leader election: "" (single process) | lockfile
+	Elector string `mapstructure:"elector"`
+
+	// Lock file shared by the directors, used by the lockfile elector
+	LockFile string `mapstructure:"lock_file"`
+
+	// URL of the RPC server of this director, as the other directors reach it when it is the leader
+	AdvertiseAddress string `mapstructure:"advertise_address"`
+
+	// What followers do with requests that change the store: proxy | reject
+	FollowerWrites string `mapstructure:"follower_writes"`
+
+	// How often followers try to become the leader and reload the shared store
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+}
+
+// DefaultHAConfig returns a default configuration for a single director
+func DefaultHAConfig() *HAConfig {
+	return &HAConfig{
+		Elector:        "",
+		LockFile:       filepath.Join(defaultDataDir, "leader.lock"),
+		FollowerWrites: FollowerWritesProxy,
+		RetryInterval:  time.Second,
+	}
+}
+
+// ValidateBasic performs basic validation (checking param bounds, etc.) and
+// returns an error if any check fails.
+func (cfg *HAConfig) ValidateBasic() error {
+	switch cfg.Elector {
+	case "":
+		return nil
+	case ElectorLockFile:
+		if cfg.LockFile == "" {
+			return errors.New("lock_file can't be empty")
+		}
+	default:
+		return errors.Errorf("unknown elector %s", cfg.Elector)
+	}
+	switch cfg.FollowerWrites {
+	case FollowerWritesProxy:
+		if cfg.AdvertiseAddress == "" {
+			return errors.New("advertise_address is required to proxy writes to the leader")
+		}
+	case FollowerWritesReject:
+	default:
+		return errors.Errorf("unknown follower_writes %s", cfg.FollowerWrites)
+	}
+	if cfg.AdvertiseAddress != "" {
+		u, err := url.Parse(cfg.AdvertiseAddress)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("advertise_address must be an http or https URL, got %s", cfg.AdvertiseAddress)
+		}
+	}
+	if cfg.RetryInterval <= 0 {
+		return errors.New("retry_interval must be positive")
+	}
+	return nil
+}
+
+// IsEnabled returns true if the director takes part in a leader election
+func (cfg *HAConfig) IsEnabled() bool {
+	return cfg.Elector != ""
+}
+
+// LockFilePath returns the full path to the lock file of the lockfile elector
+func (cfg *HAConfig) LockFilePath() string {
+	return rootify(cfg.LockFile, cfg.RootDir)
 }
 
 //-----------------------------------------------------------------------------
@@ -105,18 +448,94 @@ func DefaultRPCConfig() *tmcfg.RPCConfig {
 type TestnetsTOMLConfig struct {
 	RootDir string `mapstructure:"home"`
 
+	// Name of the [templates.<name>] section the testnet inherits its options from
+	Template string `mapstructure:"template,omitempty"`
+
 	// Timeout before director enters the 'serve' state
 	Timeout time.Duration `mapstructure:"timeout,omitempty"`
 
 	// Required minimum number of validators before director enters the 'serve' state
 	RequiredValidators uint `mapstructure:"required_validators,omitempty"`
 
-	// Todo: Low priority. Find a way to include ConsensusParams and AppState. Possibly separate JSON input.
+	// Minimum number of validators a genesis is compiled with when the timeout passes, see TimeoutPolicy.
+	// A genesis is never compiled without validators.
+	MinValidators uint `mapstructure:"min_validators,omitempty"`
+
+	// What happens when the timeout passes with less than min_validators registrations: compile | extend | fail
+	// Empty means compile, which compiles the genesis anyway if there is at least one registration.
+	TimeoutPolicy string `mapstructure:"timeout_policy,omitempty"`
+
+	// Time the registration period is extended by with the extend timeout policy
+	TimeoutExtension time.Duration `mapstructure:"timeout_extension,omitempty"`
+
+	// Number of extensions before the testnet fails with the extend timeout policy. 0 - unlimited.
+	MaxExtensions uint `mapstructure:"max_extensions,omitempty"`
+
+	// Maximum size of the genesis validator set. Registrations beyond it are waitlisted as standby full nodes,
+	// and registration stays open for standby nodes after the genesis is compiled. 0 - unlimited, no waitlist.
+	MaxValidators uint `mapstructure:"max_validators,omitempty"`
+
+	// Maximum number of registered sentry, seed and full nodes. 0 - unlimited.
+	MaxSentries  uint `mapstructure:"max_sentries,omitempty"`
+	MaxSeeds     uint `mapstructure:"max_seeds,omitempty"`
+	MaxFullNodes uint `mapstructure:"max_full_nodes,omitempty"`
+
+	// Who gets into the genesis validator set: first-come | highest-power | admin
+	// Empty means first-come. With admin, required_validators and min_validators count the selected registrations.
+	SelectionPolicy string `mapstructure:"selection_policy,omitempty"`
+
+	// Highest genesis voting power a validator can register with. 0 - every validator gets the default power.
+	MaxPower int64 `mapstructure:"max_power,omitempty"`
+
+	// Overrides of the Tendermint config.toml generated for the nodes of the testnet.
+	// Keys follow the structure of the Tendermint config file, for example consensus.timeout_commit.
+	NodeConfig map[string]interface{} `mapstructure:"node_config,omitempty"`
+
+	// Validator public key types accepted at registration and listed in the genesis consensus parameters.
+	// Empty means ed25519 only.
+	PubKeyTypes []string `mapstructure:"pub_key_types,omitempty"`
+
+	// Chain ID of the instances of a recurring testnet. {n} is replaced by the instance number, for example "game-{n}".
+	// Empty means the testnet runs once, with the chain ID of its section name.
+	ChainIDPattern string `mapstructure:"chain_id_pattern,omitempty"`
+
+	// Time between two instances of a recurring testnet. When it passes, the current instance is archived
+	// and the next one opens. 0 - the next instance opens only when the current one is archived.
+	Interval time.Duration `mapstructure:"interval,omitempty"`
+
+	// Todo: Low priority. Find a way to include the rest of ConsensusParams. Possibly separate JSON input.
 	// Genesis consensus parameters
 	//ConsensusParams string `mapstructure:"consensus_params,omitempty"`
 
-	// AppState JSON
-	//AppState json.RawMessage `mapstructure:"app_state"`
+	// Name of the compiler that builds the genesis and the address book: default | cosmos-sdk
+	GenesisCompiler string `mapstructure:"genesis_compiler,omitempty"`
+
+	// AppState JSON used as the base of the genesis app_state
+	AppState string `mapstructure:"app_state,omitempty"`
+
+	// Name of the builder that merges registered accounts into the app_state of the default compiler.
+	// Empty means app_state is used as is.
+	AppStateBuilder string `mapstructure:"app_state_builder,omitempty"`
+
+	// Default token allocation of registered accounts, for example "1000000stake,1000token"
+	AccountCoins string `mapstructure:"account_coins,omitempty"`
+}
+
+// Names accepted by the genesis_compiler and app_state_builder testnet options, besides the empty default.
+// The store registers its genesis compilers and app state builders here.
+var (
+	genesisCompilerNames = map[string]bool{}
+	appStateBuilderNames = map[string]bool{}
+)
+
+// RegisterGenesisCompilerName makes name a valid genesis_compiler, see store.RegisterGenesisCompiler.
+func RegisterGenesisCompilerName(name string) {
+	genesisCompilerNames[name] = true
+}
+
+// RegisterAppStateBuilderName makes name a valid app_state_builder, see store.RegisterAppStateBuilder.
+func RegisterAppStateBuilderName(name string) {
+	appStateBuilderNames[name] = true
 }
 
 // DefaultTestnetsTOMLConfig returns a default configuration for a Testnet
@@ -130,12 +549,165 @@ func (cfg *TestnetsTOMLConfig) ValidateBasic() error {
 	if cfg.Timeout == time.Duration(0) && cfg.RequiredValidators == 0 {
 		return errors.New("at least Timeout or RequiredValidators must be set greater than 0")
 	}
+	if cfg.RequiredValidators > 0 && cfg.MinValidators > cfg.RequiredValidators {
+		return errors.New("min_validators can't be greater than required_validators")
+	}
+	switch cfg.TimeoutPolicy {
+	case "", TimeoutPolicyCompile, TimeoutPolicyFail:
+	case TimeoutPolicyExtend:
+		if cfg.TimeoutExtension <= 0 {
+			return errors.New("timeout_extension must be greater than 0 with the extend timeout policy")
+		}
+	default:
+		return errors.Errorf("unknown timeout_policy %s", cfg.TimeoutPolicy)
+	}
+	if cfg.MaxValidators > 0 && cfg.MinValidators > cfg.MaxValidators {
+		return errors.New("min_validators can't be greater than max_validators")
+	}
+	switch cfg.SelectionPolicy {
+	case "", SelectionPolicyFirstCome, SelectionPolicyAdmin:
+	case SelectionPolicyHighestPower:
+		if cfg.MaxPower == 0 {
+			return errors.New("max_power must be greater than 0 with the highest-power selection policy")
+		}
+	default:
+		return errors.Errorf("unknown selection_policy %s", cfg.SelectionPolicy)
+	}
+	if cfg.MaxPower < 0 || cfg.MaxPower > tmtypes.MaxTotalVotingPower {
+		return errors.Errorf("max_power must be between 0 and %d", tmtypes.MaxTotalVotingPower)
+	}
+	if cfg.MaxValidators > 0 && cfg.MaxPower > tmtypes.MaxTotalVotingPower/int64(cfg.MaxValidators) {
+		return errors.Errorf("max_validators validators with max_power exceed the maximum total voting power %d", tmtypes.MaxTotalVotingPower)
+	}
+	if cfg.ChainIDPattern != "" && strings.Count(cfg.ChainIDPattern, instancePlaceholder) != 1 {
+		return errors.Errorf("chain_id_pattern must contain %s exactly once", instancePlaceholder)
+	}
+	if cfg.Interval < 0 {
+		return errors.New("interval can't be negative")
+	}
+	if cfg.Interval > 0 && cfg.ChainIDPattern == "" {
+		return errors.New("interval is only allowed with chain_id_pattern")
+	}
+	if cfg.AppState != "" && !json.Valid([]byte(cfg.AppState)) {
+		return errors.New("app_state is not valid JSON")
+	}
+	if cfg.GenesisCompiler != "" && !genesisCompilerNames[cfg.GenesisCompiler] {
+		return errors.Errorf("unknown genesis_compiler %s", cfg.GenesisCompiler)
+	}
+	if cfg.AppStateBuilder != "" && !appStateBuilderNames[cfg.AppStateBuilder] {
+		return errors.Errorf("unknown app_state_builder %s", cfg.AppStateBuilder)
+	}
+	if _, err := types.ParseCoins(cfg.AccountCoins); err != nil {
+		return errors.Wrap(err, "error in account_coins")
+	}
+	if err := cfg.ConsensusParams().Validate(); err != nil {
+		return errors.Wrap(err, "error in pub_key_types")
+	}
+	nodeConfig, err := cfg.TendermintConfig()
+	if err != nil {
+		return errors.Wrap(err, "error in node_config")
+	}
+	if err := nodeConfig.ValidateBasic(); err != nil {
+		return errors.Wrap(err, "error in node_config")
+	}
 	return nil
 }
 
+// IsRecurring returns true if the testnet runs as a series of instances with incremented chain IDs.
+func (cfg *TestnetsTOMLConfig) IsRecurring() bool {
+	return cfg.ChainIDPattern != ""
+}
+
+// InstanceChainID returns the chain ID of an instance of a recurring testnet. Instances are numbered from 1.
+func (cfg *TestnetsTOMLConfig) InstanceChainID(instance int) string {
+	return strings.Replace(cfg.ChainIDPattern, instancePlaceholder, strconv.Itoa(instance), 1)
+}
+
+// IsInstanceChainID returns true if chainID is the chain ID of an instance of the recurring testnet
+func (cfg *TestnetsTOMLConfig) IsInstanceChainID(chainID string) bool {
+	parts := strings.SplitN(cfg.ChainIDPattern, instancePlaceholder, 2)
+	if len(parts) != 2 || len(chainID) <= len(parts[0])+len(parts[1]) ||
+		!strings.HasPrefix(chainID, parts[0]) || !strings.HasSuffix(chainID, parts[1]) {
+		return false
+	}
+	instance, err := strconv.Atoi(chainID[len(parts[0]) : len(chainID)-len(parts[1])])
+	return err == nil && instance > 0 && cfg.InstanceChainID(instance) == chainID
+}
+
+// ConsensusParams returns the genesis consensus parameters of the testnet
+func (cfg *TestnetsTOMLConfig) ConsensusParams() *tmtypes.ConsensusParams {
+	result := tmtypes.DefaultConsensusParams()
+	if len(cfg.PubKeyTypes) > 0 {
+		result.Validator.PubKeyTypes = cfg.PubKeyTypes
+	}
+	return result
+}
+
+// TendermintConfig returns the default Tendermint node configuration with the NodeConfig overrides applied.
+func (cfg *TestnetsTOMLConfig) TendermintConfig() (*tmcfg.Config, error) {
+	result := tmcfg.DefaultConfig()
+	if err := decode(cfg.NodeConfig, result, true); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 //-----------------------------------------------------------------------------
 // Utils
 
+// decode decodes raw config values into a config struct the same way viper does
+func decode(input interface{}, output interface{}, errorUnused bool) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+		ErrorUnused:      errorUnused,
+		WeaklyTypedInput: true,
+		Result:           output,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
+}
+
+// toStringMap converts a raw config section to a map. A missing section is an empty map.
+func toStringMap(section interface{}) (map[string]interface{}, error) {
+	switch v := section.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return v, nil
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[fmt.Sprintf("%v", key)] = value
+		}
+		return result, nil
+	default:
+		return nil, errors.Errorf("expected a section, got %T", section)
+	}
+}
+
+// mergeStringMaps returns base with the keys of override replaced. Nested sections are merged recursively.
+func mergeStringMaps(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(base)+len(override))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range override {
+		baseSection, baseErr := toStringMap(result[key])
+		overrideSection, overrideErr := toStringMap(value)
+		if result[key] != nil && value != nil && baseErr == nil && overrideErr == nil {
+			result[key] = mergeStringMaps(baseSection, overrideSection)
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
 // helper function to make config creation independent of root dir
 func rootify(path, root string) string {
 	if filepath.IsAbs(path) {
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestnetsTOMLConfigValidateBasic(t *testing.T) {
+	RegisterAppStateBuilderName("test-builder")
+	RegisterGenesisCompilerName("test-compiler")
+
+	testCases := []struct {
+		name     string
+		modify   func(*TestnetsTOMLConfig)
+		errorMsg string
+	}{
+		{"valid", func(cfg *TestnetsTOMLConfig) {}, ""},
+		{"account coins", func(cfg *TestnetsTOMLConfig) { cfg.AccountCoins = "1000stake, 5token" }, ""},
+		{"invalid account coins", func(cfg *TestnetsTOMLConfig) { cfg.AccountCoins = "1000STAKE" }, "error in account_coins: invalid coin 1000STAKE"},
+		{"registered app state builder", func(cfg *TestnetsTOMLConfig) { cfg.AppStateBuilder = "test-builder" }, ""},
+		{"unknown app state builder", func(cfg *TestnetsTOMLConfig) { cfg.AppStateBuilder = "unknown" }, "unknown app_state_builder unknown"},
+		{"registered genesis compiler", func(cfg *TestnetsTOMLConfig) { cfg.GenesisCompiler = "test-compiler" }, ""},
+		{"unknown genesis compiler", func(cfg *TestnetsTOMLConfig) { cfg.GenesisCompiler = "unknown" }, "unknown genesis_compiler unknown"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := TestnetsTOMLConfig{RequiredValidators: 4, Timeout: time.Hour}
+			tc.modify(&cfg)
+			err := cfg.ValidateBasic()
+			if tc.errorMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.errorMsg)
+			}
+		})
+	}
+}
+
+func TestLoadConfigResolvesTemplates(t *testing.T) {
+	settings := map[string]interface{}{
+		"templates": map[string]interface{}{
+			"small": map[string]interface{}{
+				"required_validators": 4,
+				"timeout":             "2h",
+				"node_config":         map[string]interface{}{"consensus": map[string]interface{}{"timeout_commit": "1s"}},
+			},
+		},
+		"testnets": map[string]interface{}{
+			"alpha": map[string]interface{}{"template": "small"},
+			"beta": map[string]interface{}{
+				"template":            "small",
+				"required_validators": 0,
+				"node_config":         map[string]interface{}{"p2p": map[string]interface{}{"max_num_inbound_peers": 10}},
+			},
+			"gamma": map[string]interface{}{"required_validators": 2},
+		},
+	}
+
+	cfg, err := LoadConfig(settings)
+	require.NoError(t, err)
+	testnets := *cfg.Testnets
+	assert.EqualValues(t, 4, testnets["alpha"].RequiredValidators)
+	assert.Equal(t, 2*time.Hour, testnets["alpha"].Timeout)
+	assert.EqualValues(t, 0, testnets["beta"].RequiredValidators)
+	assert.Equal(t, 2*time.Hour, testnets["beta"].Timeout)
+	beta := testnets["beta"]
+	nodeConfig, err := beta.TendermintConfig()
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, nodeConfig.Consensus.TimeoutCommit)
+	assert.Equal(t, 10, nodeConfig.P2P.MaxNumInboundPeers)
+	assert.EqualValues(t, 2, testnets["gamma"].RequiredValidators)
+	assert.Equal(t, time.Duration(0), testnets["gamma"].Timeout)
+
+	settings["testnets"].(map[string]interface{})["delta"] = map[string]interface{}{"template": "missing"}
+	_, err = LoadConfig(settings)
+	assert.EqualError(t, err, "error in [testnets.delta]: unknown template missing")
+}
+
+func TestConfigValidateBasicChainIDPatterns(t *testing.T) {
+	testCases := []struct {
+		name     string
+		testnets map[string]TestnetsTOMLConfig
+		errorMsg string
+	}{
+		{"no collision", map[string]TestnetsTOMLConfig{
+			"game":   {RequiredValidators: 1, ChainIDPattern: "game-{n}"},
+			"game-x": {RequiredValidators: 1},
+			"game-0": {RequiredValidators: 1},
+			"game-":  {RequiredValidators: 1},
+		}, ""},
+		{"one-off testnet", map[string]TestnetsTOMLConfig{
+			"game":   {RequiredValidators: 1, ChainIDPattern: "game-{n}"},
+			"game-2": {RequiredValidators: 1},
+		}, "error in [testnets.game]: chain_id_pattern game-{n} matches the chain ID of [testnets.game-2]"},
+		{"suffix", map[string]TestnetsTOMLConfig{
+			"game":        {RequiredValidators: 1, ChainIDPattern: "game-{n}-test"},
+			"game-1-test": {RequiredValidators: 1},
+		}, "error in [testnets.game]: chain_id_pattern game-{n}-test matches the chain ID of [testnets.game-1-test]"},
+		{"leading zero", map[string]TestnetsTOMLConfig{
+			"game":    {RequiredValidators: 1, ChainIDPattern: "game-{n}"},
+			"game-01": {RequiredValidators: 1},
+		}, ""},
+		{"same pattern", map[string]TestnetsTOMLConfig{
+			"a": {RequiredValidators: 1, ChainIDPattern: "game-{n}"},
+			"b": {RequiredValidators: 1, ChainIDPattern: "game-{n}"},
+		}, "error in [testnets.a]: chain_id_pattern game-{n} is also used by [testnets.b]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.Testnets = &tc.testnets
+			err := cfg.ValidateBasic()
+			if tc.errorMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.errorMsg)
+			}
+		})
+	}
+}
+
+func TestBaseConfigValidateBasicDBBackend(t *testing.T) {
+	cfg := DefaultBaseConfig()
+	for _, backend := range []string{"goleveldb", "cleveldb", "boltdb", "rocksdb", "memdb", "json"} {
+		cfg.DBBackend = backend
+		assert.NoError(t, cfg.ValidateBasic(), backend)
+	}
+	cfg.DBBackend = "leveldb"
+	assert.EqualError(t, cfg.ValidateBasic(), "unknown db_backend leveldb (must be goleveldb, cleveldb, boltdb, rocksdb, memdb or json)")
+}
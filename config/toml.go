@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	tmcfg "github.com/tendermint/tendermint/config"
 	tmos "github.com/tendermint/tendermint/libs/os"
 	"path/filepath"
 	"text/template"
@@ -59,6 +60,15 @@ func WriteConfigFile(configFilePath string, config *Config) {
 	tmos.MustWriteFile(configFilePath, buffer.Bytes(), 0644)
 }
 
+// RenderTendermintConfig renders a Tendermint config.toml using the Tendermint template.
+func RenderTendermintConfig(config *tmcfg.Config) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := tendermintTemplate.Execute(&buffer, config); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
 //Todo: Low priority. Create a method to automatically save default config file (instead of writing a text string) (we don't care about comments that much)
 
 // Note: any changes to the comments/variables/mapstructure
@@ -73,7 +83,7 @@ const defaultConfigTemplate = `# This is a TOML config file.
 
 ##### main base config options #####
 
-# Database backend: goleveldb | cleveldb | boltdb | rocksdb
+# Database backend: goleveldb | cleveldb | boltdb | rocksdb | memdb | json
 # * goleveldb (github.com/syndtr/goleveldb - most popular implementation)
 #   - pure go
 #   - stable
@@ -89,6 +99,12 @@ const defaultConfigTemplate = `# This is a TOML config file.
 #   - EXPERIMENTAL
 #   - requires gcc
 #   - use rocksdb build tag (go build -tags rocksdb)
+# * memdb
+#   - keeps everything in memory, nothing survives a restart
+#   - for tests and throwaway testnets
+# * json (one plain JSON file per testnet in db_dir/testnets)
+#   - easy to inspect by hand
+#   - for lightweight deployments
 db_backend = "{{ .BaseConfig.DBBackend }}"
 
 # Database directory
@@ -100,6 +116,9 @@ log_level = "{{ .BaseConfig.LogLevel }}"
 # Output format: 'plain' (colored text) or 'json'
 log_format = "{{ .BaseConfig.LogFormat }}"
 
+# The state machine checks if a testnet has reached its timeout and changes the state to serving the genesis.
+statemachine_heartbeat = "{{ .StateMachineHeartbeat }}"
+
 ##### rpc server configuration options #####
 [rpc]
 
@@ -118,7 +137,7 @@ cors_allowed_methods = [{{ range .RPC.CORSAllowedMethods }}{{ printf "%q, " . }}
 cors_allowed_headers = [{{ range .RPC.CORSAllowedHeaders }}{{ printf "%q, " . }}{{end}}]
 
 # TCP or UNIX socket address for the gRPC server to listen on
-# NOTE: This server only supports /broadcast_tx_commit
+# It serves the DirectorAPI service defined in rpc/grpc/types.proto
 grpc_laddr = "{{ .RPC.GRPCListenAddress }}"
 
 # Maximum number of simultaneous connections.
@@ -130,7 +149,7 @@ grpc_laddr = "{{ .RPC.GRPCListenAddress }}"
 # 1024 - 40 - 10 - 50 = 924 = ~900
 grpc_max_open_connections = {{ .RPC.GRPCMaxOpenConnections }}
 
-# Activate unsafe RPC commands like /dial_seeds and /unsafe_flush_mempool
+# Activate administrative RPC commands like /archive and /snapshot
 unsafe = {{ .RPC.Unsafe }}
 
 # Maximum number of simultaneous connections (including WebSocket).
@@ -179,17 +198,135 @@ tls_cert_file = "{{ .RPC.TLSCertFile }}"
 # Otherwise, HTTP server is run.
 tls_key_file = "{{ .RPC.TLSKeyFile }}"
 
-# The state machine checks if a testnet has reached its timeout and changes the state to serving the genesis.
-statemachine_heartbeat = "{{ .StateMachineHeartbeat }}"
+# Sustained number of requests per second accepted from a single remote IP.
+# Every call of a JSON-RPC batch or of a websocket connection counts as one request. Requests above the limit get a JSON-RPC error with HTTP status 429.
+# The limits apply to gRPC calls as well, which are rejected with RESOURCE_EXHAUSTED.
+# 0 - unlimited.
+rate_limit_per_ip = {{ .RPC.RateLimitPerIP }}
+
+# Number of requests a single remote IP can make in a burst above rate_limit_per_ip
+rate_limit_per_ip_burst = {{ .RPC.RateLimitPerIPBurst }}
+
+# Sustained number of registrations per second accepted for a single chain ID.
+# 0 - unlimited.
+rate_limit_per_chain = {{ .RPC.RateLimitPerChain }}
+
+# Number of registrations a single chain ID accepts in a burst above rate_limit_per_chain
+rate_limit_per_chain_burst = {{ .RPC.RateLimitPerChainBurst }}
+
+# Number of throttled requests after which a remote IP is temporarily banned.
+# 0 - never ban.
+ban_threshold = {{ .RPC.BanThreshold }}
+
+# How long a remote IP stays banned
+ban_duration = "{{ .RPC.BanDuration }}"
+
+##### store snapshot options #####
+# Snapshots copy every testnet of the store. Take one with "director snapshot" or the /snapshot endpoint
+# (enabled with unsafe = true) and restore it with "director restore --at <snapshot>" while the node is stopped.
+[snapshots]
+
+# Directory of the snapshot files
+dir = "{{ js .Snapshots.Dir }}"
+
+# Time between periodic snapshots taken by the running node.
+# 0 - no periodic snapshots.
+interval = "{{ .Snapshots.Interval }}"
+
+# Number of snapshots kept, older ones are deleted.
+# 0 - keep all.
+retention = {{ .Snapshots.Retention }}
+
+##### high availability options #####
+# Several directors can share one store: one of them is elected leader and runs the state machine,
+# the others serve reads from the shared store and proxy or reject writes. Requires db_backend = "json"
+# with db_dir on storage all directors can reach.
+[ha]
+
+# Leader election: "" (single process) | lockfile
+# lockfile elects the director holding a lock on lock_file, which must be on storage all directors share.
+elector = "{{ .HA.Elector }}"
+
+# Lock file of the lockfile elector
+lock_file = "{{ js .HA.LockFile }}"
+
+# URL of the RPC server of this director, as the other directors reach it when it is the leader
+# Example: "http://10.0.0.1:27001"
+advertise_address = "{{ .HA.AdvertiseAddress }}"
+
+# What followers do with HTTP requests that change the store: proxy | reject
+# Websocket writes are always rejected by followers
+follower_writes = "{{ .HA.FollowerWrites }}"
+
+# How often followers try to become the leader and reload the shared store
+retry_interval = "{{ .HA.RetryInterval }}"
+
+##### testnet templates #####
+# A template holds any testnet option. Testnets set "template" to inherit them and override individual keys.
+[templates]
+
+#[templates.weekly]
+#timeout = "24h"
+#required_validators = 10
+#pub_key_types = ["ed25519"]
 
 ##### testnets configuration options #####
 [testnets]
 
+# Example testnet using a template
+#[testnets.game-1]
+#template = "weekly"
+#required_validators = 20
+
+# Example recurring testnet. A new instance (game-1, game-2, ...) opens every week,
+# or when the current instance is archived. Archived instances keep serving their files.
+#[testnets.game]
+#chain_id_pattern = "game-{n}"
+#interval = "168h"
+#timeout = "24h"
+#required_validators = 10
+
 # Example testnet with the chain ID "default".
 # Director will present genesis.json after 2 hours or when 4 validators registered, whichever comes first.
 [testnets.default]
 timeout = "2h"
 required_validators = 4
+# What happens if the timeout passes with less than min_validators registrations: compile | extend | fail
+# compile builds the genesis anyway, if anybody registered. A failed testnet can be reopened with /reopen.
+#min_validators = 2
+#timeout_policy = "extend"
+#timeout_extension = "1h"
+#max_extensions = 3
+# Size of the genesis validator set. Further registrations are waitlisted and join as standby full nodes.
+# Registration stays open for standby nodes after the genesis is compiled. 0 - unlimited, no waitlist.
+#max_validators = 10
+# Nodes can also register with the sentry, seed or full role. They carry no voting power, are listed in the
+# address book and can register after the genesis is compiled. Maximum number of each role, 0 - unlimited.
+#max_sentries = 0
+#max_seeds = 0
+#max_full_nodes = 0
+# Who gets into the genesis validator set: first-come | highest-power | admin
+# With admin, registrations are picked with /select_validator. highest-power needs max_power.
+#selection_policy = "first-come"
+# Highest genesis voting power a validator can register with.
+# 0 - validators can't choose, they all get the default power of 10.
+#max_power = 100
+# Validator public key types accepted at registration: ed25519 | secp256k1 | sr25519
+pub_key_types = ["ed25519"]
+# Compiler of the genesis and address book: default | cosmos-sdk
+# The cosmos-sdk compiler merges the accounts and gentxs submitted at registration into app_state.
+genesis_compiler = "default"
+# The default compiler can merge the submitted accounts into app_state with an app_state_builder: cosmos-sdk
+# Leave it empty to use app_state as is.
+#app_state_builder = "cosmos-sdk"
+# Default token allocation of registered accounts
+#account_coins = "1000000000stake"
+# Base app_state JSON of the genesis file
+#app_state = """{}"""
+# Overrides of the Tendermint config.toml generated by the node_config endpoint.
+# The structure follows the Tendermint config file.
+#[testnets.default.node_config.consensus]
+#timeout_commit = "5s"
 #Todo: Implement consensus_params
 #consensus_params="""
 #    "block": {
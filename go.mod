@@ -3,14 +3,20 @@ module director/m/v2
 go 1.13
 
 require (
+	github.com/golang/protobuf v1.3.2
+	github.com/gorilla/websocket v1.4.1
+	github.com/mitchellh/mapstructure v1.1.2
 	github.com/pkg/errors v0.9.1
 	github.com/rs/cors v1.7.0
-	github.com/spf13/cobra v0.0.1
+	github.com/spf13/cobra v0.0.6
+	github.com/spf13/pflag v1.0.3
 	github.com/spf13/viper v1.6.1
+	github.com/stretchr/testify v1.4.0
 	github.com/tendermint/go-amino v0.14.1
 	github.com/tendermint/tendermint v0.33.0
 	github.com/tendermint/tm-db v0.4.0
 	golang.org/x/lint v0.0.0-20200302205851-738671d3881b // indirect
 	golang.org/x/net v0.0.0-20200226121028-0de0cce0169b // indirect
 	golang.org/x/tools v0.0.0-20200421144719-26dd2a56eb2c // indirect
+	google.golang.org/grpc v1.26.0
 )
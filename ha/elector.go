@@ -0,0 +1,19 @@
+package ha
+
+import (
+	"context"
+)
+
+// Elector elects one leader among the directors sharing a store. Only the leader runs the state machine
+// and changes the store, the other directors follow.
+type Elector interface {
+	// Campaign blocks until this director is the leader, or returns the error of the context when it is done
+	Campaign(ctx context.Context) error
+	// Check returns an error if this director lost the leadership it was elected for. The leader calls it
+	// every retry interval and steps down on error, so the time two directors lead at once is bounded.
+	Check() error
+	// Resign gives up the leadership, so another director can take over
+	Resign() error
+	// Leader returns the advertised address of the current leader, or "" if there is no leader
+	Leader() (string, error)
+}
@@ -0,0 +1,153 @@
+//go:build !windows
+// +build !windows
+
+package ha
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// LockFileElector elects the director holding an exclusive lock on a file, and writes the address
+// of the leader into the file. All directors must reach the file, for example on the same host or
+// on a shared file system that supports flock. The lock is released when the process exits.
+type LockFileElector struct {
+	path          string
+	address       string
+	retryInterval time.Duration
+
+	mtx  sync.Mutex
+	file *os.File // open while leading
+}
+
+var _ Elector = (*LockFileElector)(nil)
+
+// NewLockFileElector returns an elector using the lock file at path. address is advertised to
+// the other directors while this director is the leader.
+func NewLockFileElector(path string, address string, retryInterval time.Duration) *LockFileElector {
+	return &LockFileElector{
+		path:          path,
+		address:       address,
+		retryInterval: retryInterval,
+	}
+}
+
+// Campaign implements Elector. It tries to lock the file every retry interval.
+func (e *LockFileElector) Campaign(ctx context.Context) error {
+	for {
+		ok, err := e.tryLock()
+		if err != nil || ok {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(e.retryInterval):
+		}
+	}
+}
+
+// tryLock takes the lock if it is free and advertises the address of this director
+func (e *LockFileElector) tryLock() (bool, error) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.file != nil {
+		return true, nil
+	}
+	file, err := os.OpenFile(e.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return false, err
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close() // nolint: errcheck
+		if err == syscall.EWOULDBLOCK {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := advertise(file, e.address); err != nil {
+		file.Close() // nolint: errcheck
+		return false, err
+	}
+	e.file = file
+	return true, nil
+}
+
+// Check implements Elector. The lock is lost if the lock file was removed or replaced, if it advertises another
+// director, or if the file system dropped the lock, for example a network file system that was disconnected.
+func (e *LockFileElector) Check() error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.file == nil {
+		return errors.New("not elected")
+	}
+	// Locking again succeeds while this process holds the lock
+	if err := syscall.Flock(int(e.file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		return errors.Wrap(err, "lock lost")
+	}
+	locked, err := e.file.Stat()
+	if err != nil {
+		return errors.Wrap(err, "lock file unreachable")
+	}
+	current, err := os.Stat(e.path)
+	if err != nil {
+		return errors.Wrap(err, "lock file unreachable")
+	}
+	if !os.SameFile(locked, current) {
+		return errors.New("lock file replaced")
+	}
+	content, err := ioutil.ReadFile(e.path)
+	if err != nil {
+		return errors.Wrap(err, "lock file unreachable")
+	}
+	if leader := strings.TrimSpace(string(content)); leader != e.address {
+		return errors.Errorf("lock file advertises another leader: %s", leader)
+	}
+	return nil
+}
+
+// Resign implements Elector
+func (e *LockFileElector) Resign() error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := advertise(e.file, "")
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	e.file = nil
+	return err
+}
+
+// Leader implements Elector
+func (e *LockFileElector) Leader() (string, error) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.file != nil {
+		return e.address, nil
+	}
+	content, err := ioutil.ReadFile(e.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	return strings.TrimSpace(string(content)), err
+}
+
+// advertise replaces the content of the lock file with address
+func advertise(file *os.File, address string) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt([]byte(address), 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
@@ -0,0 +1,85 @@
+//go:build !windows
+// +build !windows
+
+package ha
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLockFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "director-ha")
+	require.NoError(t, err)
+	return filepath.Join(dir, "leader.lock"), func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+// campaignFor campaigns until the timeout passes
+func campaignFor(e Elector, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return e.Campaign(ctx)
+}
+
+func TestLockFileElectorFailover(t *testing.T) {
+	path, cleanup := newTestLockFile(t)
+	defer cleanup()
+	a := NewLockFileElector(path, "http://a:27001", 10*time.Millisecond)
+	b := NewLockFileElector(path, "http://b:27001", 10*time.Millisecond)
+
+	require.NoError(t, campaignFor(a, time.Second))
+	assert.NoError(t, a.Check())
+	leader, err := b.Leader()
+	require.NoError(t, err)
+	assert.Equal(t, "http://a:27001", leader)
+
+	// Only one director leads
+	assert.Equal(t, context.DeadlineExceeded, campaignFor(b, 50*time.Millisecond))
+	assert.Error(t, b.Check())
+
+	// The follower takes over when the leader resigns
+	elected := make(chan error, 1)
+	go func() { elected <- campaignFor(b, time.Second) }()
+	require.NoError(t, a.Resign())
+	require.NoError(t, <-elected)
+	assert.NoError(t, b.Check())
+	assert.Error(t, a.Check())
+	leader, err = a.Leader()
+	require.NoError(t, err)
+	assert.Equal(t, "http://b:27001", leader)
+}
+
+func TestLockFileElectorNoticesLostLock(t *testing.T) {
+	path, cleanup := newTestLockFile(t)
+	defer cleanup()
+	a := NewLockFileElector(path, "http://a:27001", 10*time.Millisecond)
+	require.NoError(t, campaignFor(a, time.Second))
+	require.NoError(t, a.Check())
+
+	// The lock file disappears, for example it was cleaned up by hand, and another director locks a new one
+	require.NoError(t, os.Remove(path))
+	assert.Error(t, a.Check())
+	b := NewLockFileElector(path, "http://b:27001", 10*time.Millisecond)
+	require.NoError(t, campaignFor(b, time.Second))
+	assert.Error(t, a.Check())
+	assert.NoError(t, b.Check())
+	require.NoError(t, a.Resign())
+	assert.NoError(t, b.Check())
+}
+
+func TestLockFileElectorNoticesOtherLeader(t *testing.T) {
+	path, cleanup := newTestLockFile(t)
+	defer cleanup()
+	a := NewLockFileElector(path, "http://a:27001", 10*time.Millisecond)
+	require.NoError(t, campaignFor(a, time.Second))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("http://b:27001"), 0600))
+	assert.Error(t, a.Check())
+}
@@ -0,0 +1,37 @@
+package ha
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// LockFileElector is not supported on Windows, which has no flock
+type LockFileElector struct{}
+
+var _ Elector = (*LockFileElector)(nil)
+
+// NewLockFileElector returns an elector that always fails on Windows
+func NewLockFileElector(path string, address string, retryInterval time.Duration) *LockFileElector {
+	return &LockFileElector{}
+}
+
+// Campaign implements Elector
+func (e *LockFileElector) Campaign(ctx context.Context) error {
+	return errors.New("the lockfile elector is not supported on windows")
+}
+
+// Check implements Elector
+func (e *LockFileElector) Check() error {
+	return errors.New("the lockfile elector is not supported on windows")
+}
+
+// Resign implements Elector
+func (e *LockFileElector) Resign() error {
+	return nil
+}
+
+// Leader implements Elector
+func (e *LockFileElector) Leader() (string, error) {
+	return "", nil
+}
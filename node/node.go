@@ -1,24 +1,32 @@
 package node
 
 import (
+	"context"
 	cfg "director/m/v2/config"
+	"director/m/v2/ha"
 	"director/m/v2/rpc/core"
 	rpccore "director/m/v2/rpc/core"
+	grpccore "director/m/v2/rpc/grpc"
+	"director/m/v2/rpc/jsonrpc"
+	"director/m/v2/rpc/middleware"
 	"director/m/v2/state"
 	"director/m/v2/store"
 	"director/m/v2/version"
+	"errors"
+	"fmt"
 	"github.com/rs/cors"
 	"github.com/tendermint/go-amino"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/service"
 	"github.com/tendermint/tendermint/p2p/pex"
 	rpccoretypes "github.com/tendermint/tendermint/rpc/core/types"
-	grpccore "github.com/tendermint/tendermint/rpc/grpc"
 	rpcserver "github.com/tendermint/tendermint/rpc/lib/server"
 	dbm "github.com/tendermint/tm-db"
 	"net"
 	"net/http"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -64,33 +72,84 @@ type Node struct {
 	// services
 	rpcListeners []net.Listener // rpc servers
 	stateMachine *state.Machine // state machine service for each testnet
+	testnetStore store.Store
+
+	// leader election, nil in single process mode
+	elector        ha.Elector
+	leading        int32 // 1 while this node runs the state machine
+	cancelCampaign context.CancelFunc
+	campaignDone   chan struct{} // closed when the campaign stopped
 }
 
-func initDBs(config *cfg.Config, dbProvider DBProvider) (mystore *store.TestnetDB, err error) {
-	var storeDB dbm.DB
-	storeDB, err = dbProvider(&DBContext{"testnetDB", config})
-	if err != nil {
-		return
+// CustomElector sets the leader election of the node. The directors taking part must share the store.
+func CustomElector(elector ha.Elector) Option {
+	return func(n *Node) {
+		n.elector = elector
+	}
+}
+
+func initDBs(config *cfg.Config, dbProvider DBProvider, logger log.Logger) (mystore store.Store, err error) {
+	var testnetDB *store.TestnetDB
+	if config.DBBackend == cfg.DBBackendJSON {
+		testnetDB = store.NewJSONStore(jsonStoreDir(config), *config.Testnets)
+	} else {
+		var storeDB dbm.DB
+		storeDB, err = dbProvider(&DBContext{"testnetDB", config})
+		if err != nil {
+			return
+		}
+		testnetDB = store.NewStore(storeDB, *config.Testnets)
 	}
-	mystore = store.NewStore(storeDB, *config.Testnets)
+	testnetDB.SetLogger(logger)
+	mystore = testnetDB
 
 	return
 }
 
+// OpenKeySpace opens the raw content of the testnet store, to take or restore snapshots while the node is stopped.
+// closer releases the database.
+func OpenKeySpace(config *cfg.Config, dbProvider DBProvider) (keySpace store.KeySpace, closer func() error, err error) {
+	switch config.DBBackend {
+	case cfg.DBBackendJSON:
+		keySpace, err = store.NewJSONKeySpace(jsonStoreDir(config))
+		return keySpace, func() error { return nil }, err
+	case string(dbm.MemDBBackend):
+		return nil, nil, errors.New("the memdb backend only exists in the running node, use the snapshot endpoint")
+	}
+
+	// tm-db panics if the database can't be opened, for example because the node holds its lock
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("error opening the database (is the node running?): %v", r)
+		}
+	}()
+	storeDB, err := dbProvider(&DBContext{"testnetDB", config})
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.NewDBKeySpace(storeDB), storeDB.Close, nil
+}
+
+// jsonStoreDir returns the directory of the json db_backend
+func jsonStoreDir(config *cfg.Config) string {
+	return filepath.Join(config.DBDir(), "testnets")
+}
+
 // NewNode returns a new, ready to go, Director.
 func NewNode(config *cfg.Config,
 	dbProvider DBProvider,
 	logger log.Logger,
 	options ...Option) (*Node, error) {
 
-	testnetStore, err := initDBs(config, dbProvider)
+	testnetStore, err := initDBs(config, dbProvider, logger.With("module", "store"))
 	if err != nil {
 		return nil, err
 	}
 
 	// Create state machine
 	stateMachineLogger := logger.With("module", "state")
-	stateMachine := createStateMachine(testnetStore, stateMachineLogger, *config.StateMachineHeartbeat)
+	stateMachine := createStateMachine(testnetStore, stateMachineLogger, *config.StateMachineHeartbeat,
+		state.WithSnapshots(config.Snapshots.SnapshotDir(), config.Snapshots.Interval, config.Snapshots.Retention))
 
 	// Log the version info.
 	logger.Info("Version info",
@@ -100,6 +159,10 @@ func NewNode(config *cfg.Config,
 	node := &Node{
 		config:       config,
 		stateMachine: stateMachine,
+		testnetStore: testnetStore,
+	}
+	if config.HA.Elector == cfg.ElectorLockFile {
+		node.elector = ha.NewLockFileElector(config.HA.LockFilePath(), config.HA.AdvertiseAddress, config.HA.RetryInterval)
 	}
 	node.BaseService = *service.NewBaseService(logger, "Node", node)
 
@@ -113,10 +176,14 @@ func NewNode(config *cfg.Config,
 // OnStart starts the Node. It implements service.Service.
 func (n *Node) OnStart() error {
 
-	// Start StateMachine
-	err := n.stateMachine.Start()
-	if err != nil {
-		panic(err)
+	if n.elector == nil {
+		// Single process: this node is the leader
+		n.startLeading()
+	} else {
+		var ctx context.Context
+		ctx, n.cancelCampaign = context.WithCancel(context.Background())
+		n.campaignDone = make(chan struct{})
+		go n.campaign(ctx)
 	}
 
 	// Start the RPC server
@@ -135,8 +202,21 @@ func (n *Node) OnStart() error {
 func (n *Node) OnStop() {
 	n.BaseService.OnStop()
 
+	if n.cancelCampaign != nil {
+		n.cancelCampaign()
+		<-n.campaignDone
+	}
+	atomic.StoreInt32(&n.leading, 0)
+
 	//Stop State Machine
-	_ = n.stateMachine.Stop()
+	if n.stateMachine.IsRunning() {
+		_ = n.stateMachine.Stop()
+	}
+	if n.elector != nil {
+		if err := n.elector.Resign(); err != nil {
+			n.Logger.Error("Error resigning leadership", "err", err)
+		}
+	}
 
 	n.Logger.Info("Stopping Node")
 
@@ -156,16 +236,126 @@ func (n *Node) OnStop() {
 	//}
 }
 
+// startLeading starts the state machine, which changes the store from now on
+func (n *Node) startLeading() {
+	// Start StateMachine
+	err := n.stateMachine.Start()
+	if err != nil {
+		panic(err)
+	}
+	atomic.StoreInt32(&n.leading, 1)
+}
+
+// stopLeading stops the state machine and gives up the leadership
+func (n *Node) stopLeading() {
+	atomic.StoreInt32(&n.leading, 0)
+	if err := n.stateMachine.Stop(); err != nil {
+		n.Logger.Error("Error stopping the state machine", "err", err)
+	}
+	if err := n.stateMachine.Reset(); err != nil {
+		n.Logger.Error("Error resetting the state machine", "err", err)
+	}
+	if err := n.elector.Resign(); err != nil {
+		n.Logger.Error("Error resigning leadership", "err", err)
+	}
+}
+
+// campaign follows the leader until this node is elected, then leads until it loses the leadership,
+// and starts over until ctx is done
+func (n *Node) campaign(ctx context.Context) {
+	defer close(n.campaignDone)
+	for n.follow(ctx) {
+		if !n.lead(ctx) {
+			return
+		}
+	}
+}
+
+// follow keeps reloading the shared store until this node is elected leader, then starts leading.
+// It returns false if the election failed or ctx is done.
+func (n *Node) follow(ctx context.Context) bool {
+	elected := make(chan error, 1)
+	go func() { elected <- n.elector.Campaign(ctx) }()
+	ticker := time.NewTicker(n.config.HA.RetryInterval)
+	defer ticker.Stop()
+	n.Logger.Info("Following the leader")
+	for {
+		select {
+		case err := <-elected:
+			if err != nil {
+				if ctx.Err() == nil {
+					n.Logger.Error("Leader election failed", "err", err)
+				}
+				return false
+			}
+			// Pick up the last changes of the previous leader before taking over
+			if err := n.testnetStore.Reload(); err != nil {
+				n.Logger.Error("Error reloading the store, resigning", "err", err)
+				if err := n.elector.Resign(); err != nil {
+					n.Logger.Error("Error resigning leadership", "err", err)
+				}
+				go func() { elected <- n.elector.Campaign(ctx) }()
+				continue
+			}
+			n.Logger.Info("Elected leader")
+			n.startLeading()
+			return true
+		case <-ticker.C:
+			if err := n.testnetStore.Reload(); err != nil {
+				n.Logger.Error("Error reloading the store", "err", err)
+			}
+		}
+	}
+}
+
+// lead checks the leadership every retry interval and steps down when it is lost.
+// It returns false when ctx is done, OnStop stops leading then.
+func (n *Node) lead(ctx context.Context) bool {
+	ticker := time.NewTicker(n.config.HA.RetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			if err := n.elector.Check(); err != nil {
+				n.Logger.Error("Lost the leadership, stepping down", "err", err)
+				n.stopLeading()
+				return true
+			}
+		}
+	}
+}
+
+// IsLeader returns true if this node runs the state machine. It implements core.Leadership.
+func (n *Node) IsLeader() bool {
+	return atomic.LoadInt32(&n.leading) == 1
+}
+
+// Leader returns the advertised address of the leader. It implements core.Leadership.
+func (n *Node) Leader() (string, error) {
+	if n.elector == nil {
+		return n.config.HA.AdvertiseAddress, nil
+	}
+	return n.elector.Leader()
+}
+
 // ConfigureRPC sets all variables in rpccore so they will serve
 // rpc calls from this node
 func (n *Node) ConfigureRPC() {
 	rpccore.SetStateMachine(n.stateMachine)
 	rpccore.SetLogger(n.Logger.With("module", "rpc"))
-	rpccore.SetConfig(*n.config.RPC)
+	rpccore.SetConfig(n.config.RPC.RPCConfig)
+	if n.elector != nil {
+		rpccore.SetLeadership(n)
+	}
 }
 
 func (n *Node) startRPC() ([]net.Listener, error) {
 	n.ConfigureRPC()
+	if n.config.RPC.Unsafe {
+		rpccore.AddUnsafeRoutes()
+	}
 	listenAddrs := splitAndTrimEmpty(n.config.RPC.ListenAddress, ",", " ")
 	coreCodec := amino.NewCodec()
 	rpccoretypes.RegisterAmino(coreCodec)
@@ -182,19 +372,25 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 		config.WriteTimeout = n.config.RPC.TimeoutBroadcastTxCommit + 1*time.Second
 	}
 
+	// the limits are shared by all listeners
+	rateLimiter := middleware.NewRateLimiter(n.config.RPC, n.Logger.With("module", "rpc-ratelimit"))
+
 	// we may expose the rpc over both a unix and tcp socket
 	listeners := make([]net.Listener, len(listenAddrs))
 	for i, listenAddr := range listenAddrs {
 		mux := http.NewServeMux()
 		rpcLogger := n.Logger.With("module", "rpc-server")
 		wmLogger := rpcLogger.With("protocol", "websocket")
-		wm := rpcserver.NewWebsocketManager(core.Routes, coreCodec,
-			rpcserver.OnDisconnect(func(remoteAddr string) {}),
-			rpcserver.ReadLimit(config.MaxBodyBytes),
-		)
+		wm := jsonrpc.NewWebsocketManager(core.Routes, coreCodec, config.MaxBodyBytes)
 		wm.SetLogger(wmLogger)
+		if n.config.RPC.IsRateLimitEnabled() {
+			// the limiter only sees the upgrade of a connection, the calls are charged one by one
+			wm.SetCallFilter(rateLimiter.CheckWebsocketCall)
+		}
 		mux.HandleFunc("/websocket", wm.WebsocketHandler)
-		rpcserver.RegisterRPCFuncs(mux, core.Routes, coreCodec, rpcLogger)
+		mux.HandleFunc(core.FilesPath, core.FileHandler)
+		mux.HandleFunc(core.RESTPath, core.RESTHandler)
+		jsonrpc.RegisterRPCFuncs(mux, core.Routes, coreCodec, rpcLogger)
 		listener, err := rpcserver.Listen(
 			listenAddr,
 			config,
@@ -204,13 +400,20 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 		}
 
 		var rootHandler http.Handler = mux
+		if n.elector != nil {
+			proxy := n.config.HA.FollowerWrites == cfg.FollowerWritesProxy
+			rootHandler = middleware.NewLeaderGuard(n, proxy, n.Logger.With("module", "rpc-leader")).Handler(rootHandler)
+		}
 		if n.config.RPC.IsCorsEnabled() {
 			corsMiddleware := cors.New(cors.Options{
 				AllowedOrigins: n.config.RPC.CORSAllowedOrigins,
 				AllowedMethods: n.config.RPC.CORSAllowedMethods,
 				AllowedHeaders: n.config.RPC.CORSAllowedHeaders,
 			})
-			rootHandler = corsMiddleware.Handler(mux)
+			rootHandler = corsMiddleware.Handler(rootHandler)
+		}
+		if n.config.RPC.IsRateLimitEnabled() {
+			rootHandler = rateLimiter.Handler(rootHandler)
 		}
 		if n.config.RPC.IsTLSEnabled() {
 			go rpcserver.StartHTTPAndTLSServer(
@@ -233,16 +436,28 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 		listeners[i] = listener
 	}
 
-	// we expose a simplified api over grpc for convenience to app devs
+	// we expose the registration and the testnet files over grpc for convenience to app devs
 	grpcListenAddr := n.config.RPC.GRPCListenAddress
 	if grpcListenAddr != "" {
 		config := rpcserver.DefaultConfig()
-		config.MaxOpenConnections = n.config.RPC.MaxOpenConnections
+		config.MaxOpenConnections = n.config.RPC.GRPCMaxOpenConnections
 		listener, err := rpcserver.Listen(grpcListenAddr, config)
 		if err != nil {
 			return nil, err
 		}
-		go grpccore.StartGRPCServer(listener)
+		grpcConfig := grpccore.Config{}
+		if n.elector != nil {
+			grpcConfig.Leadership = n
+			grpcConfig.ProxyWrites = n.config.HA.FollowerWrites == cfg.FollowerWritesProxy
+		}
+		if n.config.RPC.IsRateLimitEnabled() {
+			grpcConfig.RateLimiter = rateLimiter
+		}
+		go func() {
+			if err := grpccore.StartGRPCServer(listener, grpcConfig); err != nil {
+				n.Logger.Info("gRPC server stopped", "err", err)
+			}
+		}()
 		listeners = append(listeners, listener)
 	}
 
@@ -250,8 +465,8 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 }
 
 // Create State Machine
-func createStateMachine(testnetDB *store.TestnetDB, stateLogger log.Logger, timeoutInterval time.Duration) *state.Machine {
-	return state.NewMachine(testnetDB, stateLogger, timeoutInterval)
+func createStateMachine(testnetDB store.Store, stateLogger log.Logger, timeoutInterval time.Duration, options ...state.MachineOption) *state.Machine {
+	return state.NewMachine(testnetDB, stateLogger, timeoutInterval, options...)
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+	cfg "director/m/v2/config"
+	"director/m/v2/ha"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+)
+
+// newHANode returns a node without RPC server sharing the JSON store and the lock file under shared
+func newHANode(t *testing.T, shared string, name string) *Node {
+	config := cfg.DefaultConfig().SetRoot(filepath.Join(shared, name))
+	config.DBBackend = cfg.DBBackendJSON
+	config.DBPath = filepath.Join(shared, "data")
+	config.RPC.ListenAddress = ""
+	config.HA.Elector = cfg.ElectorLockFile
+	config.HA.LockFile = filepath.Join(shared, "leader.lock")
+	config.HA.AdvertiseAddress = "http://" + name + ":27001"
+	config.HA.RetryInterval = 10 * time.Millisecond
+	node, err := DefaultNewNode(config, log.NewNopLogger())
+	require.NoError(t, err)
+	return node
+}
+
+// waitFor polls cond until it is true or a few seconds passed
+func waitFor(t *testing.T, cond func() bool, message string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(message)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNodeFailover(t *testing.T) {
+	shared, err := ioutil.TempDir("", "director-node")
+	require.NoError(t, err)
+	defer os.RemoveAll(shared) // nolint: errcheck
+
+	a := newHANode(t, shared, "a")
+	require.NoError(t, a.Start())
+	waitFor(t, a.IsLeader, "a not elected")
+
+	b := newHANode(t, shared, "b")
+	require.NoError(t, b.Start())
+	defer b.Stop() // nolint: errcheck
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, b.IsLeader())
+	leader, err := b.Leader()
+	require.NoError(t, err)
+	assert.Equal(t, "http://a:27001", leader)
+
+	// The follower takes over when the leader stops
+	require.NoError(t, a.Stop())
+	waitFor(t, b.IsLeader, "b did not take over")
+	assert.False(t, a.IsLeader())
+}
+
+func TestNodeStepsDownWhenTheLockIsLost(t *testing.T) {
+	shared, err := ioutil.TempDir("", "director-node")
+	require.NoError(t, err)
+	defer os.RemoveAll(shared) // nolint: errcheck
+
+	a := newHANode(t, shared, "a")
+	require.NoError(t, a.Start())
+	defer a.Stop() // nolint: errcheck
+	waitFor(t, a.IsLeader, "a not elected")
+
+	// The lock file is replaced and another director locks it
+	require.NoError(t, os.Remove(filepath.Join(shared, "leader.lock")))
+	other := ha.NewLockFileElector(filepath.Join(shared, "leader.lock"), "http://other:27001", 10*time.Millisecond)
+	require.NoError(t, other.Campaign(context.Background()))
+	defer other.Resign() // nolint: errcheck
+
+	waitFor(t, func() bool { return !a.IsLeader() }, "a did not step down")
+	waitFor(t, func() bool {
+		leader, err := a.Leader()
+		return err == nil && leader == "http://other:27001"
+	}, "a does not follow the other director")
+	assert.False(t, a.stateMachine.IsRunning())
+
+	// a leads again, with a new state machine, once the other director resigns
+	require.NoError(t, other.Resign())
+	waitFor(t, a.IsLeader, "a not elected again")
+	assert.True(t, a.stateMachine.IsRunning())
+}
@@ -0,0 +1,18 @@
+package core
+
+import (
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// Archive closes a testnet and keeps its files available read-only.
+// Archiving the latest instance of a recurring testnet opens the next instance.
+func Archive(ctx *rpctypes.Context, chainID string) (*ResultArchive, error) {
+	next, err := stateMachine.ArchiveTestnet(chainID)
+	if err != nil {
+		return nil, err
+	}
+	return &ResultArchive{
+		ChainID:     chainID,
+		NextChainID: next,
+	}, nil
+}
@@ -0,0 +1,11 @@
+package core
+
+import (
+	"director/m/v2/types"
+	"fmt"
+)
+
+// errInvalidParam returns the error of a request parameter rejected by the RPC layer
+func errInvalidParam(param string, format string, args ...interface{}) *types.Error {
+	return &types.Error{Code: types.CodeInvalidParam, Message: fmt.Sprintf(format, args...), Data: types.ErrorData{Param: param}}
+}
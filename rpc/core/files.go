@@ -0,0 +1,289 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"director/m/v2/store"
+	"director/m/v2/types"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/tendermint/tendermint/p2p"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilesPath is the URL prefix for raw file downloads: /files/<chain_id>/<file>
+const FilesPath = "/files/"
+
+// checksumSuffix is appended to a file name to download its SHA-256 checksum
+const checksumSuffix = ".sha256"
+
+// fileFunc renders a testnet file and returns its content and modification time.
+// The query holds the URL parameters of the request.
+type fileFunc func(chainID string, query url.Values) ([]byte, time.Time, error)
+
+// files lists the downloadable files of a testnet
+var files = map[string]fileFunc{
+	"genesis.json":  genesisFile,
+	"addrbook.json": addressBookFile,
+	"peers.txt":     peersFile,
+	"config.toml":   nodeConfigFile,
+}
+
+// FileHandler serves testnet files as plain HTTP downloads, without the JSON-RPC envelope.
+// A SHA-256 checksum in `sha256sum` format is available by adding ".sha256" to the file name.
+func FileHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, FilesPath), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	chainID, name := parts[0], parts[1]
+
+	checksum := strings.HasSuffix(name, checksumSuffix)
+	render, ok := files[strings.TrimSuffix(name, checksumSuffix)]
+	if !ok {
+		render = artifactFile(strings.TrimSuffix(name, checksumSuffix))
+	}
+
+	content, modTime, err := render(chainID, r.URL.Query())
+	if err != nil {
+		writeFileError(w, chainID, name, err)
+		return
+	}
+	sum := sha256.Sum256(content)
+	contentType := mime.TypeByExtension(path.Ext(strings.TrimSuffix(name, checksumSuffix)))
+	if checksum {
+		content = []byte(fmt.Sprintf("%x  %s\n", sum, strings.TrimSuffix(name, checksumSuffix)))
+		contentType = "text/plain; charset=utf-8"
+		sum = sha256.Sum256(content)
+	}
+
+	// The ETag is weak, because the gzipped and the plain representations are equivalent.
+	etag := fmt.Sprintf(`W/"%s"`, hex.EncodeToString(sum[:]))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	w.Header().Set("Vary", "Accept-Encoding")
+	w.Header().Set("Cache-Control", "no-cache")
+	if notModified(r, etag, modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		var buffer bytes.Buffer
+		gz := gzip.NewWriter(&buffer)
+		if _, err := gz.Write(content); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := gz.Close(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		content = buffer.Bytes()
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(content); err != nil {
+		logger.Error("Error writing file", "chain_id", chainID, "file", name, "err", err)
+	}
+}
+
+// writeFileError reports a failed download. Typed errors get the HTTP status of the REST API,
+// other errors are internal errors.
+func writeFileError(w http.ResponseWriter, chainID string, name string, err error) {
+	if e, ok := types.AsError(err); ok {
+		http.Error(w, e.Message, httpStatus(e.Code))
+		return
+	}
+	logger.Error("Error rendering file", "chain_id", chainID, "file", name, "err", err)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
+
+// acceptsGzip evaluates the Accept-Encoding request header. gzip is accepted if it, or "*", is listed with a
+// non-zero quality value. An explicit gzip entry takes precedence over "*".
+func acceptsGzip(header string) bool {
+	accepted, wildcard := false, false
+	for _, entry := range strings.Split(header, ",") {
+		params := strings.Split(entry, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding != "gzip" && coding != "x-gzip" && coding != "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err != nil {
+				q = 0
+			}
+			quality = q
+		}
+		if coding == "*" {
+			wildcard = quality > 0
+			continue
+		}
+		if quality == 0 {
+			return false
+		}
+		accepted = true
+	}
+	return accepted || wildcard
+}
+
+// notModified evaluates the If-None-Match and If-Modified-Since request headers
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil {
+		return !modTime.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// GenesisFile returns the genesis.json of a testnet as it is downloaded from FilesPath
+func GenesisFile(chainID string) ([]byte, error) {
+	content, _, err := genesisFile(chainID, nil)
+	return content, err
+}
+
+// AddressBookFile returns the addrbook.json of a testnet as it is downloaded from FilesPath
+func AddressBookFile(chainID string) ([]byte, error) {
+	content, _, err := addressBookFile(chainID, nil)
+	return content, err
+}
+
+// genesisFile renders genesis.json the same way Tendermint writes it
+func genesisFile(chainID string, _ url.Values) ([]byte, time.Time, error) {
+	genesis, err := stateMachine.GetGenesis(chainID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	content, err := tmtypes.GetCodec().MarshalJSONIndent(genesis.Genesis, "", "  ")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return content, genesis.Genesis.GenesisTime, nil
+}
+
+// lastModified returns the modification time of the files built from the registrations of a testnet.
+// Late registrations change them after the genesis, so it is the time of the last change of the testnet.
+// It is read before the content, so a change in between can only make the content newer.
+func lastModified(chainID string) (time.Time, error) {
+	genesis, err := stateMachine.GetGenesis(chainID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	status, err := stateMachine.GetStatus(chainID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// testnets stored before UpdatedAt was recorded
+	if status.UpdatedAt.Before(genesis.Genesis.GenesisTime) {
+		return genesis.Genesis.GenesisTime, nil
+	}
+	return status.UpdatedAt, nil
+}
+
+// addressBookFile renders addrbook.json the same way Tendermint writes it
+func addressBookFile(chainID string, _ url.Values) ([]byte, time.Time, error) {
+	modTime, err := lastModified(chainID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	addressBook, err := stateMachine.GetAddressBook(chainID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	content, err := json.MarshalIndent(addressBook, "", "\t")
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return content, modTime, nil
+}
+
+// peersFile renders the comma-separated peer list.
+// It accepts the `exclude`, `limit` and `seeds_only` query parameters of the peers RPC.
+func peersFile(chainID string, query url.Values) ([]byte, time.Time, error) {
+	modTime, err := lastModified(chainID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	options := store.PeersOptions{
+		Exclude: p2p.ID(query.Get("exclude")),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if options.Limit, err = strconv.Atoi(limit); err != nil {
+			return nil, time.Time{}, errInvalidParam("limit", "limit must be a number: %s", limit)
+		}
+	}
+	if seedsOnly := query.Get("seeds_only"); seedsOnly != "" {
+		if options.SeedsOnly, err = strconv.ParseBool(seedsOnly); err != nil {
+			return nil, time.Time{}, errInvalidParam("seeds_only", "seeds_only must be a boolean: %s", seedsOnly)
+		}
+	}
+	peers, err := stateMachine.GetPeers(chainID, options)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return []byte(strings.Join(peers, ",") + "\n"), modTime, nil
+}
+
+// nodeConfigFile renders the Tendermint config.toml of the node given in the `node_id` or `pub_key` query parameter
+func nodeConfigFile(chainID string, query url.Values) ([]byte, time.Time, error) {
+	modTime, err := lastModified(chainID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	result, err := NodeConfig(nil, chainID, query.Get("pub_key"), query.Get("node_id"))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return []byte(result.Config), modTime, nil
+}
+
+// artifactFile returns a fileFunc serving an extra file of the genesis compiler
+func artifactFile(name string) fileFunc {
+	return func(chainID string, _ url.Values) ([]byte, time.Time, error) {
+		genesis, err := stateMachine.GetGenesis(chainID)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		artifact, err := stateMachine.GetArtifact(chainID, name)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		return artifact, genesis.Genesis.GenesisTime, nil
+	}
+}
@@ -0,0 +1,227 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	cfg "director/m/v2/config"
+	"director/m/v2/state"
+	"director/m/v2/store"
+	"director/m/v2/types"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// setupTestnets makes the routes serve the testnets of an in-memory store
+func setupTestnets(t *testing.T, testnets map[string]cfg.TestnetsTOMLConfig) {
+	logger := log.NewNopLogger()
+	SetLogger(logger)
+	SetStateMachine(state.NewMachine(store.NewStore(dbm.NewMemDB(), testnets), logger, time.Second))
+	SetLeadership(nil)
+}
+
+// registerTestNode registers a node with new keys and an IP address and returns its public key
+func registerTestNode(t *testing.T, chainID string, name string, role string) string {
+	_, pubKey, err := types.PubKeyToBase64(ed25519.GenPrivKey().PubKey())
+	require.NoError(t, err)
+	nodeID := p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())
+	_, err = Register(&rpctypes.Context{}, chainID, name, pubKey, p2p.IDAddressString(nodeID, "127.0.0.1:26656"), false, "", "", "", 0, role, "", false)
+	require.NoError(t, err)
+	return pubKey
+}
+
+// getFile serves a request of the file handler with the headers given as name-value pairs
+func getFile(method string, path string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	FileHandler(w, r)
+	return w
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	testCases := []struct {
+		header string
+		gzip   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"x-gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"deflate", false},
+		{"identity", false},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"gzip;q=invalid", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"identity, *;q=0.1", true},
+		// an explicit gzip entry takes precedence over "*"
+		{"gzip;q=0, *", false},
+		{"*;q=0, gzip", true},
+		{"x-gzip;q=0, *;q=1", false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.gzip, acceptsGzip(tc.header), "Accept-Encoding: %q", tc.header)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := `W/"abc"`
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 500000000, time.UTC)
+	at := func(d time.Duration) string {
+		return modTime.Add(d).Format(http.TimeFormat)
+	}
+	testCases := []struct {
+		name            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		notModified     bool
+	}{
+		{"no conditions", "", "", false},
+		{"weak etag", `W/"abc"`, "", true},
+		{"strong etag of the same content", `"abc"`, "", true},
+		{"etag in a list", `"other", W/"abc"`, "", true},
+		{"any etag", "*", "", true},
+		{"other etag", `W/"other"`, "", false},
+		{"other etag takes precedence over the date", `"other"`, at(time.Hour), false},
+		{"matching etag takes precedence over the date", `"abc"`, at(-time.Hour), true},
+		{"same second", "", at(0), true},
+		{"later", "", at(time.Hour), true},
+		{"earlier", "", at(-time.Second), false},
+		{"invalid date", "", "yesterday", false},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tc.ifNoneMatch)
+		}
+		if tc.ifModifiedSince != "" {
+			r.Header.Set("If-Modified-Since", tc.ifModifiedSince)
+		}
+		assert.Equal(t, tc.notModified, notModified(r, etag, modTime), tc.name)
+	}
+}
+
+func TestFileHandler(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{
+		"default": {RequiredValidators: 1},
+		"pending": {RequiredValidators: 2},
+	})
+	registerTestNode(t, "default", "validator1", "")
+
+	w := getFile(http.MethodGet, "/files/default/genesis.json")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	content := w.Body.Bytes()
+	assert.Contains(t, string(content), `"chain_id": "default"`)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="genesis.json"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, fmt.Sprintf("%d", len(content)), w.Header().Get("Content-Length"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	etag := w.Header().Get("ETag")
+	assert.Equal(t, fmt.Sprintf(`W/"%x"`, sha256.Sum256(content)), etag)
+	lastModified := w.Header().Get("Last-Modified")
+	require.NotEmpty(t, lastModified)
+
+	t.Run("conditional requests", func(t *testing.T) {
+		w := getFile(http.MethodGet, "/files/default/genesis.json", "If-None-Match", etag)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+		w = getFile(http.MethodGet, "/files/default/genesis.json", "If-Modified-Since", lastModified)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		w = getFile(http.MethodGet, "/files/default/genesis.json", "If-None-Match", `W/"other"`, "If-Modified-Since", lastModified)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		w := getFile(http.MethodGet, "/files/default/genesis.json", "Accept-Encoding", "gzip")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		gz, err := gzip.NewReader(bytes.NewReader(w.Body.Bytes()))
+		require.NoError(t, err)
+		plain, err := ioutil.ReadAll(gz)
+		require.NoError(t, err)
+		assert.Equal(t, content, plain)
+
+		w = getFile(http.MethodGet, "/files/default/genesis.json", "Accept-Encoding", "gzip;q=0")
+		assert.Empty(t, w.Header().Get("Content-Encoding"))
+		assert.Equal(t, content, w.Body.Bytes())
+	})
+
+	t.Run("HEAD", func(t *testing.T) {
+		w := getFile(http.MethodHead, "/files/default/genesis.json")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Body.Bytes())
+		assert.Equal(t, fmt.Sprintf("%d", len(content)), w.Header().Get("Content-Length"))
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	})
+
+	t.Run("checksum", func(t *testing.T) {
+		w := getFile(http.MethodGet, "/files/default/genesis.json.sha256")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, fmt.Sprintf("%x  genesis.json\n", sha256.Sum256(content)), w.Body.String())
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Equal(t, fmt.Sprintf(`W/"%x"`, sha256.Sum256(w.Body.Bytes())), w.Header().Get("ETag"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		w := getFile(http.MethodPost, "/files/default/genesis.json")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, "GET, HEAD", w.Header().Get("Allow"))
+		assert.Equal(t, http.StatusNotFound, getFile(http.MethodGet, "/files/default").Code)
+		assert.Equal(t, http.StatusNotFound, getFile(http.MethodGet, "/files/unknown/genesis.json").Code)
+		assert.Equal(t, http.StatusNotFound, getFile(http.MethodGet, "/files/default/unknown.json").Code)
+		assert.Equal(t, http.StatusConflict, getFile(http.MethodGet, "/files/pending/genesis.json").Code)
+		assert.Equal(t, http.StatusBadRequest, getFile(http.MethodGet, "/files/default/peers.txt?limit=x").Code)
+	})
+}
+
+// Late registrations change the address book, the peers and the node configs after the genesis is compiled
+func TestFilesModifiedByLateRegistrations(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 1}})
+	pubKey := registerTestNode(t, "default", "validator1", "")
+
+	paths := []string{
+		"/files/default/addrbook.json",
+		"/files/default/peers.txt",
+		"/files/default/config.toml?pub_key=" + url.QueryEscape(pubKey),
+	}
+	lastModified := map[string]string{}
+	for _, path := range paths {
+		w := getFile(http.MethodGet, path)
+		require.Equal(t, http.StatusOK, w.Code, path)
+		lastModified[path] = w.Header().Get("Last-Modified")
+		w = getFile(http.MethodGet, path, "If-Modified-Since", lastModified[path])
+		assert.Equal(t, http.StatusNotModified, w.Code, path)
+	}
+	genesis := getFile(http.MethodGet, "/files/default/genesis.json").Header().Get("Last-Modified")
+
+	// Last-Modified has a resolution of a second
+	time.Sleep(time.Second)
+	registerTestNode(t, "default", "sentry1", "sentry")
+	for _, path := range paths {
+		w := getFile(http.MethodGet, path, "If-Modified-Since", lastModified[path])
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.NotEqual(t, lastModified[path], w.Header().Get("Last-Modified"), path)
+	}
+	// The genesis does not change
+	w := getFile(http.MethodGet, "/files/default/genesis.json", "If-Modified-Since", genesis)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	rpc "director/m/v2/rpc/jsonrpc"
+	"director/m/v2/types"
+	"reflect"
+)
+
+// WriteMethods lists the RPC methods that change the store. Only the leader serves them.
+var WriteMethods = map[string]bool{
+	"register":         true,
+	"register_json":    true,
+	"archive":          true,
+	"reopen":           true,
+	"select_validator": true,
+}
+
+// Leadership tells if this director is the leader of the directors sharing a store
+type Leadership interface {
+	IsLeader() bool
+	// Leader returns the advertised address of the current leader, or "" if there is no leader
+	Leader() (string, error)
+}
+
+// ErrNotLeader returns the error of writes sent to a follower. leader is the advertised address of the leader, "" if there is no leader.
+func ErrNotLeader(leader string) *types.Error {
+	if leader == "" {
+		return types.NewError(types.CodeNotLeader, "this director is a follower and there is no leader")
+	}
+	return &types.Error{
+		Code:    types.CodeNotLeader,
+		Message: "this director is a follower, send writes to the leader at " + leader,
+		Data:    types.ErrorData{Leader: leader},
+	}
+}
+
+// CheckLeader returns an ErrNotLeader if leadership is set and this director is a follower
+func CheckLeader(leadership Leadership) error {
+	if leadership == nil || leadership.IsLeader() {
+		return nil
+	}
+	leader, err := leadership.Leader()
+	if err != nil {
+		logger.Error("Can't find the leader", "err", err)
+	}
+	return ErrNotLeader(leader)
+}
+
+// newWriteRPCFunc creates the route of a write method, see WriteMethods.
+// HTTP requests are proxied or rejected by the middleware before they reach the route,
+// the route rejects the calls of websocket connections to followers.
+func newWriteRPCFunc(f interface{}, args string) *rpc.RPCFunc {
+	return rpc.NewRPCFunc(leaderOnly(f), args)
+}
+
+// leaderOnly wraps a route function, which returns a result and an error, to reject calls while this director is a follower
+func leaderOnly(f interface{}) interface{} {
+	fv := reflect.ValueOf(f)
+	return reflect.MakeFunc(fv.Type(), func(args []reflect.Value) []reflect.Value {
+		if err := CheckLeader(leadership); err != nil {
+			return []reflect.Value{reflect.Zero(fv.Type().Out(0)), reflect.ValueOf(&err).Elem()}
+		}
+		return fv.Call(args)
+	}).Interface()
+}
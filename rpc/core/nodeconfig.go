@@ -0,0 +1,28 @@
+package core
+
+import (
+	cfg "director/m/v2/config"
+	"github.com/tendermint/tendermint/p2p"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// NodeConfig returns a ready to use Tendermint config.toml for a registered node.
+// The node is identified by its validator public key or by its node ID.
+func NodeConfig(ctx *rpctypes.Context, chainID string, pubKey string, nodeID string) (*ResultNodeConfig, error) {
+	if pubKey == "" && nodeID == "" {
+		return nil, errInvalidParam("pub_key", "pub_key or node_id is required")
+	}
+	nodeConfig, err := stateMachine.GetNodeConfig(chainID, pubKey, p2p.ID(nodeID))
+	if err != nil {
+		return nil, err
+	}
+	content, err := cfg.RenderTendermintConfig(nodeConfig.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &ResultNodeConfig{
+		NodeID:  nodeConfig.NodeID,
+		Moniker: nodeConfig.Config.Moniker,
+		Config:  string(content),
+	}, nil
+}
@@ -0,0 +1,170 @@
+package core
+
+// openAPIDocument describes the REST API. It is served at /v1/openapi.json.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "Tendermint Director REST API",
+    "description": "Registrations and genesis files of Tendermint testnets. The same operations are available over JSON-RPC.",
+    "version": "1"
+  },
+  "paths": {
+    "/v1/testnets": {
+      "get": {
+        "operationId": "listTestnets",
+        "summary": "Status of every testnet, including the archived instances of recurring testnets",
+        "responses": {
+          "200": {
+            "description": "Testnets",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Testnets"}}}
+          },
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/testnets/{chain_id}": {
+      "parameters": [{"$ref": "#/components/parameters/ChainID"}],
+      "get": {
+        "operationId": "getTestnet",
+        "summary": "State and registration progress of a testnet",
+        "responses": {
+          "200": {
+            "description": "Testnet status",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Status"}}}
+          },
+          "404": {"$ref": "#/components/responses/Error"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/testnets/{chain_id}/registrations": {
+      "parameters": [{"$ref": "#/components/parameters/ChainID"}],
+      "post": {
+        "operationId": "register",
+        "summary": "Register a node",
+        "description": "Register with pub_key and net_address, or with the public parts of priv_validator_key.json and node_key.json together with host and port. Registering the same key again updates the registration.",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RegistrationRequest"}}}
+        },
+        "responses": {
+          "201": {
+            "description": "Registered",
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Registration"}}}
+          },
+          "400": {"$ref": "#/components/responses/Error"},
+          "404": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    },
+    "/v1/testnets/{chain_id}/genesis": {
+      "parameters": [{"$ref": "#/components/parameters/ChainID"}],
+      "get": {
+        "operationId": "getGenesis",
+        "summary": "Compiled genesis, in the format of Tendermint's genesis.json",
+        "responses": {
+          "200": {
+            "description": "Genesis document",
+            "content": {"application/json": {"schema": {"type": "object"}}}
+          },
+          "404": {"$ref": "#/components/responses/Error"},
+          "409": {"$ref": "#/components/responses/Error"},
+          "default": {"$ref": "#/components/responses/Error"}
+        }
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "ChainID": {
+        "name": "chain_id",
+        "in": "path",
+        "required": true,
+        "schema": {"type": "string"}
+      }
+    },
+    "responses": {
+      "Error": {
+        "description": "Error with a stable code, see the Errors section of the README",
+        "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ErrorResponse"}}}
+      }
+    },
+    "schemas": {
+      "Status": {
+        "type": "object",
+        "properties": {
+          "chain_id": {"type": "string"},
+          "state": {"type": "string"},
+          "validators": {"type": "integer"},
+          "required_validators": {"type": "integer"},
+          "min_validators": {"type": "integer"},
+          "max_validators": {"type": "integer"},
+          "standby": {"type": "integer", "description": "Waitlisted registrations, or standby nodes once the genesis is compiled"},
+          "nodes": {"type": "integer", "description": "Sentry, seed and full node registrations"},
+          "deadline": {"type": "string", "format": "date-time", "description": "End of the registration period, omitted if the testnet has no timeout"},
+          "extensions": {"type": "integer"},
+          "timeout_outcome": {"type": "string"}
+        }
+      },
+      "Testnets": {
+        "type": "object",
+        "properties": {
+          "testnets": {"type": "array", "items": {"$ref": "#/components/schemas/Status"}}
+        }
+      },
+      "RegistrationRequest": {
+        "type": "object",
+        "properties": {
+          "name": {"type": "string", "description": "Moniker of the node"},
+          "pub_key": {"type": "string", "description": "Base64 public key of the validator"},
+          "key_type": {"type": "string", "description": "Type of pub_key, ed25519 by default"},
+          "net_address": {"type": "string", "description": "id@host:port address of the node"},
+          "priv_validator_key": {"type": "object", "description": "Public part of priv_validator_key.json: address and pub_key"},
+          "node_key": {"type": "object", "description": "Public part of node_key.json: id or pub_key"},
+          "host": {"type": "string", "description": "Host of the node, with priv_validator_key and node_key"},
+          "port": {"type": "integer", "description": "P2P port of the node, with priv_validator_key and node_key"},
+          "seed": {"type": "boolean"},
+          "account_address": {"type": "string", "description": "Bech32 account of Cosmos-SDK testnets"},
+          "gentx": {"type": "object", "description": "Signed gentx of Cosmos-SDK testnets"},
+          "power": {"type": "integer"},
+          "role": {"type": "string", "enum": ["validator", "sentry", "seed", "full"]},
+          "sentries": {"type": "array", "items": {"type": "string"}, "description": "Node IDs of the sentries of a private validator"},
+          "private": {"type": "boolean"}
+        }
+      },
+      "Registration": {
+        "type": "object",
+        "properties": {
+          "chain_id": {"type": "string"},
+          "node_id": {"type": "string"},
+          "role": {"type": "string"}
+        }
+      },
+      "ErrorResponse": {
+        "type": "object",
+        "properties": {
+          "error": {
+            "type": "object",
+            "properties": {
+              "code": {"type": "integer"},
+              "message": {"type": "string"},
+              "data": {
+                "type": "object",
+                "properties": {
+                  "chain_id": {"type": "string"},
+                  "state": {"type": "string"},
+                  "limit": {"type": "string"},
+                  "param": {"type": "string"},
+                  "leader": {"type": "string"}
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}
+`
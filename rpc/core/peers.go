@@ -0,0 +1,24 @@
+package core
+
+import (
+	"director/m/v2/store"
+	"github.com/tendermint/tendermint/p2p"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"strings"
+)
+
+// Peers returns the seeds or persistent_peers string for a testnet.
+// The node ID in exclude is left out, limit picks a random subset and seedsOnly filters for seed nodes.
+func Peers(ctx *rpctypes.Context, chainID string, exclude string, limit int, seedsOnly bool) (*ResultPeers, error) {
+	peers, err := stateMachine.GetPeers(chainID, store.PeersOptions{
+		Exclude:   p2p.ID(exclude),
+		Limit:     limit,
+		SeedsOnly: seedsOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ResultPeers{
+		Peers: strings.Join(peers, ","),
+	}, nil
+}
@@ -2,6 +2,8 @@ package core
 
 import (
 	"director/m/v2/state"
+	"director/m/v2/types"
+	"net"
 	"time"
 
 	cfg "github.com/tendermint/tendermint/config"
@@ -26,6 +28,10 @@ var (
 	logger log.Logger
 
 	config cfg.RPCConfig
+
+	leadership Leadership
+
+	resolver types.Resolver = types.ResolverFunc(net.LookupIP)
 )
 
 // SetLogger sets the RPC logger
@@ -42,3 +48,13 @@ func SetStateMachine(m *state.Machine) {
 func SetConfig(c cfg.RPCConfig) {
 	config = c
 }
+
+// SetLeadership makes the write methods reject calls while this director is a follower
+func SetLeadership(l Leadership) {
+	leadership = l
+}
+
+// SetResolver sets the resolver looking up the DNS names of registered nodes. The default is the system resolver.
+func SetResolver(r types.Resolver) {
+	resolver = r
+}
@@ -1,47 +1,163 @@
 package core
 
 import (
+	"bytes"
 	"director/m/v2/store"
 	"director/m/v2/types"
-	"encoding/base64"
-	"errors"
-	"github.com/tendermint/tendermint/crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/tendermint/tendermint/libs/bech32"
+	"github.com/tendermint/tendermint/p2p"
 	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"net"
+	"strconv"
+	"strings"
 )
 
-// Register a node for a testnet
-func Register(ctx *rpctypes.Context, chainID string, name string, pubKey string, netAddress string) (*rpctypes.RPCError, error) {
-	// Check ed25519 compatibiliy
-	pubBytes, err := base64.StdEncoding.DecodeString(pubKey)
+// Register a node for a testnet. The key type defaults to ed25519.
+// The account address and the gentx JSON are optional, they are merged into the genesis app_state.
+// The genesis voting power defaults to 10. The role is validator (default), sentry, seed or full,
+// only validators go into the genesis validator set.
+// A node behind sentries lists their comma-separated node IDs in sentries and sets private to hide its own address.
+func Register(ctx *rpctypes.Context, chainID string, name string, pubKey string, netAddress string, seed bool, keyType string, accountAddress string, genTx string, power int64, role string, sentries string, private bool) (*ResultRegister, error) {
+	return registerValidator(chainID, netAddress, sentries, store.ValidatorConfig{
+		Name:           name,
+		PubKey:         pubKey,
+		KeyType:        keyType,
+		Seed:           seed,
+		AccountAddress: accountAddress,
+		GenTx:          json.RawMessage(genTx),
+		Power:          power,
+		Role:           role,
+		Private:        private,
+	})
+}
+
+// RegisterJSON registers a node for a testnet using the public parts of the Tendermint key files.
+// The node ID is derived from the node key and the network address is built from host and port.
+func RegisterJSON(ctx *rpctypes.Context, chainID string, name string, privValidatorKey PrivValidatorKeyJSON, nodeKey NodeKeyJSON, host string, port uint16, seed bool, accountAddress string, genTx string, power int64, role string, sentries string, private bool) (*ResultRegister, error) {
+	if privValidatorKey.PubKey == nil {
+		return nil, errInvalidParam("priv_validator_key", "missing priv_validator_key pub_key")
+	}
+	if len(privValidatorKey.Address) > 0 && !bytes.Equal(privValidatorKey.Address, privValidatorKey.PubKey.Address()) {
+		return nil, errInvalidParam("priv_validator_key", "priv_validator_key address does not match pub_key")
+	}
+	keyType, pubKey, err := types.PubKeyToBase64(privValidatorKey.PubKey)
 	if err != nil {
-		return nil, err
+		return nil, errInvalidParam("priv_validator_key", "%v", err)
+	}
+
+	nodeID := nodeKey.ID
+	if nodeKey.PubKey != nil {
+		nodeID = p2p.PubKeyToID(nodeKey.PubKey)
+		if nodeKey.ID != "" && nodeKey.ID != nodeID {
+			return nil, errInvalidParam("node_key", "node_key id does not match pub_key")
+		}
 	}
-	if len(pubBytes) != ed25519.PubKeyEd25519Size {
-		return nil, errors.New("invalid ed25519 public key length")
+	if nodeID == "" {
+		return nil, errInvalidParam("node_key", "missing node_key pub_key or id")
+	}
+
+	netAddress := p2p.IDAddressString(nodeID, net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)))
+	return registerValidator(chainID, netAddress, sentries, store.ValidatorConfig{
+		Name:           name,
+		PubKey:         pubKey,
+		KeyType:        keyType,
+		Seed:           seed,
+		AccountAddress: accountAddress,
+		GenTx:          json.RawMessage(genTx),
+		Power:          power,
+		Role:           role,
+		Private:        private,
+	})
+}
+
+// registerValidator validates the registration details and registers the node
+func registerValidator(chainID string, netAddress string, sentries string, validator store.ValidatorConfig) (*ResultRegister, error) {
+	// Check key type compatibiliy
+	_, err := types.PubKeyFromBase64(validator.KeyType, validator.PubKey)
+	if err != nil {
+		return nil, errInvalidParam("pub_key", "%v", err)
 	}
 
 	// Validate network address
-	netAddressStruct, err := types.NewNetAddressString(netAddress)
+	validator.NetAddress, err = types.NewNetAddressString(netAddress, resolver)
 	if err != nil {
-		return nil, err
+		return nil, errInvalidParam("net_address", "%v", err)
 	}
-	err = netAddressStruct.Valid()
+	err = validator.NetAddress.Valid()
 	if err != nil {
-		return nil, err
+		return nil, errInvalidParam("net_address", "%v", err)
+	}
+
+	// Validate role
+	if !store.IsValidRole(validator.Role) {
+		return nil, errInvalidParam("role", "invalid role, expected validator, sentry, seed or full")
+	}
+	if validator.Role == store.RoleSeed {
+		validator.Seed = true
 	}
+	if !validator.IsValidator() && (validator.Power != 0 || len(validator.GenTx) > 0) {
+		return nil, errInvalidParam("role", "power and gentx are only accepted from validators")
+	}
+
+	// Validate sentries
+	for _, sentry := range strings.Split(sentries, ",") {
+		sentry = strings.TrimSpace(sentry)
+		if sentry == "" {
+			continue
+		}
+		if err := validateID(p2p.ID(sentry)); err != nil {
+			return nil, errInvalidParam("sentries", "invalid sentry node ID: %v", err)
+		}
+		validator.Sentries = append(validator.Sentries, p2p.ID(sentry))
+	}
+	if validator.Private && len(validator.Sentries) == 0 {
+		return nil, errInvalidParam("sentries", "private nodes must list their sentries")
+	}
+
+	// Validate voting power
+	if validator.Power < 0 || validator.Power > tmtypes.MaxTotalVotingPower {
+		return nil, errInvalidParam("power", "invalid power")
+	}
+
+	// Validate application account
+	if validator.AccountAddress != "" {
+		if _, _, err := bech32.DecodeAndConvert(validator.AccountAddress); err != nil {
+			return nil, errInvalidParam("account_address", "invalid account address: %v", err)
+		}
+	}
+	if len(validator.GenTx) == 0 {
+		validator.GenTx = nil
+	} else if !json.Valid(validator.GenTx) {
+		return nil, errInvalidParam("gentx", "gentx is not valid JSON")
+	}
+
 	// Sync registration
-	err = stateMachine.RegisterValidator(chainID, store.ValidatorConfig{
-		NetAddress: netAddressStruct,
-		Name:       name,
-		PubKey:     pubKey,
-	})
-	if err != nil {
+	if err := stateMachine.RegisterValidator(chainID, validator); err != nil {
 		return nil, err
 	}
-	// Success registering
-	return &rpctypes.RPCError{
-		Code:    0,
-		Message: "Registered",
-		Data:    "",
+	role := validator.Role
+	if role == "" {
+		role = store.RoleValidator
+	}
+	return &ResultRegister{
+		ChainID: chainID,
+		NodeID:  validator.NetAddress.ID,
+		Role:    role,
 	}, nil
 }
+
+// validateID checks the format of a node ID, the same way p2p.NetAddress does
+func validateID(id p2p.ID) error {
+	idBytes, err := hex.DecodeString(string(id))
+	if err != nil {
+		return err
+	}
+	if len(idBytes) != p2p.IDByteLength {
+		return fmt.Errorf("invalid hex length - got %d, expected %d", len(idBytes), p2p.IDByteLength)
+	}
+	return nil
+}
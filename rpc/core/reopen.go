@@ -0,0 +1,17 @@
+package core
+
+import (
+	"director/m/v2/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// Reopen restarts the registration period of a failed testnet. Existing registrations are kept.
+func Reopen(ctx *rpctypes.Context, chainID string) (*ResultReopen, error) {
+	if err := stateMachine.ReopenTestnet(chainID); err != nil {
+		return nil, err
+	}
+	return &ResultReopen{
+		ChainID: chainID,
+		State:   types.Gather.String(),
+	}, nil
+}
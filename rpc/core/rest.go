@@ -0,0 +1,241 @@
+package core
+
+import (
+	"director/m/v2/types"
+	"encoding/json"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RESTPath is the URL prefix of the REST API
+const RESTPath = "/v1/"
+
+// restHandlerFunc serves a REST route and returns the HTTP status and the result of a successful call.
+// chainID is the {chain_id} segment of the path, if the route has one.
+type restHandlerFunc func(ctx *rpctypes.Context, chainID string, r *http.Request) (int, interface{}, error)
+
+// restRoute maps an HTTP method and path to a handler
+type restRoute struct {
+	method string
+	// Path segments after RESTPath. "{chain_id}" matches any chain ID.
+	path []string
+	// Name of the JSON-RPC route with the same effect
+	rpcMethod string
+	handle    restHandlerFunc
+}
+
+// restRoutes lists the REST API. openapi.json describes it.
+var restRoutes = []restRoute{
+	{http.MethodGet, []string{"openapi.json"}, "", restOpenAPI},
+	{http.MethodGet, []string{"testnets"}, "testnets", restTestnets},
+	{http.MethodGet, []string{"testnets", "{chain_id}"}, "status", restStatus},
+	{http.MethodPost, []string{"testnets", "{chain_id}", "registrations"}, "register", restRegister},
+	{http.MethodGet, []string{"testnets", "{chain_id}", "genesis"}, "genesis", restGenesis},
+}
+
+// RegistrationRequest is the body of a REST registration.
+// A node registers with pub_key and net_address, or with the public parts of its Tendermint key files
+// in priv_validator_key and node_key together with host and port, the same way as register_json.
+type RegistrationRequest struct {
+	Name             string          `json:"name"`
+	PubKey           string          `json:"pub_key"`
+	KeyType          string          `json:"key_type"`
+	NetAddress       string          `json:"net_address"`
+	PrivValidatorKey json.RawMessage `json:"priv_validator_key"`
+	NodeKey          json.RawMessage `json:"node_key"`
+	Host             string          `json:"host"`
+	Port             uint16          `json:"port"`
+	Seed             bool            `json:"seed"`
+	AccountAddress   string          `json:"account_address"`
+	GenTx            json.RawMessage `json:"gentx"`
+	Power            int64           `json:"power"`
+	Role             string          `json:"role"`
+	Sentries         []string        `json:"sentries"`
+	Private          bool            `json:"private"`
+}
+
+// RESTHandler serves the REST API with plain JSON bodies and HTTP status codes.
+// Errors are returned as {"error": {"code": ..., "message": ..., "data": {...}}} with the codes of the JSON-RPC API.
+// Request bodies are bounded by max_body_bytes, even without the rate limiter.
+func RESTHandler(w http.ResponseWriter, r *http.Request) {
+	route, chainID, allowed := matchREST(r)
+	if route == nil {
+		if allowed != "" {
+			w.Header().Set("Allow", allowed)
+			WriteRESTError(w, http.StatusMethodNotAllowed, types.NewError(types.CodeMethodNotFound, "method not allowed"))
+			return
+		}
+		WriteRESTError(w, http.StatusNotFound, types.NewError(types.CodeMethodNotFound, "not found"))
+		return
+	}
+
+	if r.Body != nil && config.MaxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, config.MaxBodyBytes)
+	}
+	status, result, err := route.handle(&rpctypes.Context{HTTPReq: r}, chainID, r)
+	if err != nil {
+		e := types.ToError(err)
+		WriteRESTError(w, httpStatus(e.Code), e)
+		return
+	}
+
+	content, ok := result.(json.RawMessage)
+	if !ok {
+		if content, err = json.MarshalIndent(result, "", "  "); err != nil {
+			WriteRESTError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeREST(w, status, content)
+}
+
+// RESTMethod returns the name of the JSON-RPC route equivalent to a REST request and its chain ID,
+// so middlewares can treat both APIs the same way. The method is empty if no route matches.
+func RESTMethod(r *http.Request) (method string, chainID string) {
+	route, chainID, _ := matchREST(r)
+	if route == nil {
+		return "", ""
+	}
+	return route.rpcMethod, chainID
+}
+
+// matchREST finds the route of a request. If only the HTTP method does not match, the allowed methods are returned.
+func matchREST(r *http.Request) (match *restRoute, chainID string, allowed string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.EscapedPath(), RESTPath), "/"), "/")
+	var methods []string
+	for i := range restRoutes {
+		route := &restRoutes[i]
+		id, ok := matchPath(route.path, segments)
+		if !ok {
+			continue
+		}
+		if route.method == r.Method || (route.method == http.MethodGet && r.Method == http.MethodHead) {
+			return route, id, ""
+		}
+		methods = append(methods, route.method)
+	}
+	return nil, "", strings.Join(methods, ", ")
+}
+
+// matchPath matches the path segments of a request with the path of a route and returns the chain ID segment
+func matchPath(path []string, segments []string) (chainID string, ok bool) {
+	if len(path) != len(segments) {
+		return "", false
+	}
+	for i, segment := range segments {
+		if path[i] == "{chain_id}" {
+			id, err := url.PathUnescape(segment)
+			if err != nil || id == "" {
+				return "", false
+			}
+			chainID = id
+			continue
+		}
+		if path[i] != segment {
+			return "", false
+		}
+	}
+	return chainID, true
+}
+
+// httpStatus maps the code of a typed error to an HTTP status
+func httpStatus(code types.ErrorCode) int {
+	switch code {
+	case types.CodeInvalidParam, types.CodeParseError, types.CodeInvalidRequest, types.CodeKeyTypeNotAccepted:
+		return http.StatusBadRequest
+	case types.CodeUnregisteredTestnet, types.CodeUnregisteredNode, types.CodeFileNotFound, types.CodeMethodNotFound:
+		return http.StatusNotFound
+	case types.CodeTestnetNotReady, types.CodeRegistrationClosed, types.CodeLimitReached,
+		types.CodeRegistrationLocked, types.CodeInvalidStateChange:
+		return http.StatusConflict
+	case types.CodeTooManyRequests:
+		return http.StatusTooManyRequests
+	case types.CodeNotLeader:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// WriteRESTError writes an error response of the REST API. Untyped errors are internal errors.
+func WriteRESTError(w http.ResponseWriter, status int, err error) {
+	e := types.ToError(err)
+	content, err := json.MarshalIndent(struct {
+		Error *types.Error `json:"error"`
+	}{e}, "", "  ")
+	if err != nil {
+		http.Error(w, e.Message, status)
+		return
+	}
+	writeREST(w, status, content)
+}
+
+func writeREST(w http.ResponseWriter, status int, content []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(content); err != nil {
+		logger.Error("Error writing REST response", "err", err)
+	}
+}
+
+//------------------------------------------------------------------------------
+// Routes
+
+func restOpenAPI(ctx *rpctypes.Context, _ string, r *http.Request) (int, interface{}, error) {
+	return http.StatusOK, json.RawMessage(openAPIDocument), nil
+}
+
+func restTestnets(ctx *rpctypes.Context, _ string, r *http.Request) (int, interface{}, error) {
+	result, err := Testnets(ctx)
+	return http.StatusOK, result, err
+}
+
+func restStatus(ctx *rpctypes.Context, chainID string, r *http.Request) (int, interface{}, error) {
+	result, err := Status(ctx, chainID)
+	return http.StatusOK, result, err
+}
+
+// restGenesis returns the genesis the same way Tendermint writes genesis.json
+func restGenesis(ctx *rpctypes.Context, chainID string, r *http.Request) (int, interface{}, error) {
+	content, err := GenesisFile(chainID)
+	return http.StatusOK, json.RawMessage(content), err
+}
+
+func restRegister(ctx *rpctypes.Context, chainID string, r *http.Request) (int, interface{}, error) {
+	var req RegistrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return 0, nil, types.NewError(types.CodeParseError, "invalid request body: %v", err)
+	}
+	sentries := strings.Join(req.Sentries, ",")
+	var genTx string
+	if isSet(req.GenTx) {
+		genTx = string(req.GenTx)
+	}
+
+	var result *ResultRegister
+	var err error
+	if isSet(req.PrivValidatorKey) || isSet(req.NodeKey) {
+		var privValidatorKey PrivValidatorKeyJSON
+		var nodeKey NodeKeyJSON
+		if err := tmtypes.GetCodec().UnmarshalJSON(req.PrivValidatorKey, &privValidatorKey); err != nil {
+			return 0, nil, errInvalidParam("priv_validator_key", "invalid priv_validator_key: %v", err)
+		}
+		if err := tmtypes.GetCodec().UnmarshalJSON(req.NodeKey, &nodeKey); err != nil {
+			return 0, nil, errInvalidParam("node_key", "invalid node_key: %v", err)
+		}
+		result, err = RegisterJSON(ctx, chainID, req.Name, privValidatorKey, nodeKey, req.Host, req.Port, req.Seed,
+			req.AccountAddress, genTx, req.Power, req.Role, sentries, req.Private)
+	} else {
+		result, err = Register(ctx, chainID, req.Name, req.PubKey, req.NetAddress, req.Seed, req.KeyType,
+			req.AccountAddress, genTx, req.Power, req.Role, sentries, req.Private)
+	}
+	return http.StatusCreated, result, err
+}
+
+// isSet tells if a JSON field of a request body is present and not null
+func isSet(field json.RawMessage) bool {
+	return len(field) > 0 && string(field) != "null"
+}
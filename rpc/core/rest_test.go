@@ -0,0 +1,222 @@
+package core
+
+import (
+	cfg "director/m/v2/config"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/p2p"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// callREST serves a request of the REST API and decodes the response into result, or returns its error
+func callREST(t *testing.T, method string, path string, body string, result interface{}) (*httptest.ResponseRecorder, *types.Error) {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	RESTHandler(w, r)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	if w.Code >= 400 {
+		var response struct {
+			Error *types.Error `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+		require.NotNil(t, response.Error, w.Body.String())
+		return w, response.Error
+	}
+	if result != nil && method != http.MethodHead {
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), result), w.Body.String())
+	}
+	return w, nil
+}
+
+// newRESTRegistration returns the body of a registration with a public key and a net address, and null for the other objects
+func newRESTRegistration(t *testing.T, name string) string {
+	_, pubKey, err := types.PubKeyToBase64(ed25519.GenPrivKey().PubKey())
+	require.NoError(t, err)
+	nodeID := p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())
+	body, err := json.Marshal(RegistrationRequest{Name: name, PubKey: pubKey, NetAddress: p2p.IDAddressString(nodeID, "127.0.0.1:26656")})
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestRESTRoutes(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 2}})
+
+	var testnets ResultTestnets
+	w, err := callREST(t, http.MethodGet, "/v1/testnets", "", &testnets)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.Len(t, testnets.Testnets, 1)
+	assert.Equal(t, "default", testnets.Testnets[0].ChainID)
+
+	var status ResultStatus
+	_, err = callREST(t, http.MethodGet, "/v1/testnets/default", "", &status)
+	require.Nil(t, err)
+	assert.Equal(t, "gather", status.State)
+
+	// Registration with a public key and a net address
+	var registered ResultRegister
+	w, err = callREST(t, http.MethodPost, "/v1/testnets/default/registrations", newRESTRegistration(t, "validator1"), &registered)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "default", registered.ChainID)
+	assert.Equal(t, "validator", registered.Role)
+
+	_, err = callREST(t, http.MethodGet, "/v1/testnets/default/genesis", "", nil)
+	require.NotNil(t, err)
+	assert.Equal(t, types.CodeTestnetNotReady, err.Code)
+
+	// Registration with the public parts of the Tendermint key files
+	privValidatorKey, jsonErr := tmtypes.GetCodec().MarshalJSON(PrivValidatorKeyJSON{PubKey: ed25519.GenPrivKey().PubKey()})
+	require.NoError(t, jsonErr)
+	nodeKey := ed25519.GenPrivKey().PubKey()
+	body := fmt.Sprintf(`{"name": "validator2", "priv_validator_key": %s, "node_key": {"id": "%s"}, "host": "127.0.0.1", "port": 26656}`,
+		privValidatorKey, p2p.PubKeyToID(nodeKey))
+	registered = ResultRegister{}
+	w, err = callREST(t, http.MethodPost, "/v1/testnets/default/registrations", body, &registered)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, p2p.PubKeyToID(nodeKey), registered.NodeID)
+
+	// Null fields are absent fields
+	sentry := newRESTRegistration(t, "sentry1")
+	sentry = strings.TrimSuffix(sentry, "}") + `, "role": "sentry"}`
+	_, err = callREST(t, http.MethodPost, "/v1/testnets/default/registrations", sentry, &registered)
+	require.Nil(t, err)
+	assert.Equal(t, "sentry", registered.Role)
+
+	var genesis tmtypes.GenesisDoc
+	w, err = callREST(t, http.MethodGet, "/v1/testnets/default/genesis", "", nil)
+	require.Nil(t, err)
+	require.NoError(t, tmtypes.GetCodec().UnmarshalJSON(w.Body.Bytes(), &genesis))
+	assert.Equal(t, "default", genesis.ChainID)
+	assert.Len(t, genesis.Validators, 2)
+
+	w, err = callREST(t, http.MethodHead, "/v1/testnets/default/genesis", "", nil)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var document map[string]interface{}
+	_, err = callREST(t, http.MethodGet, "/v1/openapi.json", "", &document)
+	require.Nil(t, err)
+	assert.Contains(t, document, "paths")
+}
+
+func TestRESTErrors(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 1}})
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   types.ErrorCode
+	}{
+		{"unknown path", http.MethodGet, "/v1/unknown", "", http.StatusNotFound, types.CodeMethodNotFound},
+		{"unknown testnet", http.MethodGet, "/v1/testnets/unknown", "", http.StatusNotFound, types.CodeUnregisteredTestnet},
+		{"malformed body", http.MethodPost, "/v1/testnets/default/registrations", `{"name": `, http.StatusBadRequest, types.CodeParseError},
+		{"invalid public key", http.MethodPost, "/v1/testnets/default/registrations", `{"name": "validator", "pub_key": "invalid"}`,
+			http.StatusBadRequest, types.CodeInvalidParam},
+		{"invalid node key", http.MethodPost, "/v1/testnets/default/registrations", `{"name": "validator", "node_key": 1}`,
+			http.StatusBadRequest, types.CodeInvalidParam},
+	}
+	for _, tc := range testCases {
+		w, err := callREST(t, tc.method, tc.path, tc.body, nil)
+		assert.Equal(t, tc.status, w.Code, tc.name)
+		require.NotNil(t, err, tc.name)
+		assert.Equal(t, tc.code, err.Code, tc.name)
+	}
+
+	_, err := callREST(t, http.MethodGet, "/v1/testnets/unknown", "", nil)
+	assert.Equal(t, "unknown", err.Data.ChainID)
+
+	// The registration of the only validator compiles the genesis and closes the registration
+	w, err := callREST(t, http.MethodPost, "/v1/testnets/default/registrations", newRESTRegistration(t, "validator1"), nil)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w, err = callREST(t, http.MethodPost, "/v1/testnets/default/registrations", newRESTRegistration(t, "validator2"), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	require.NotNil(t, err)
+	assert.Equal(t, types.CodeRegistrationClosed, err.Code)
+	assert.Equal(t, "serve", err.Data.State)
+}
+
+func TestRESTMethodNotAllowed(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 1}})
+
+	w, err := callREST(t, http.MethodPut, "/v1/testnets/default/registrations", "", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+	require.NotNil(t, err)
+	assert.Equal(t, types.CodeMethodNotFound, err.Code)
+
+	w, _ = callREST(t, http.MethodDelete, "/v1/testnets/default", "", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "GET", w.Header().Get("Allow"))
+}
+
+func TestRESTUnescapesChainIDs(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{"a/b": {RequiredValidators: 1}, "test net": {RequiredValidators: 1}})
+
+	var status ResultStatus
+	_, err := callREST(t, http.MethodGet, "/v1/testnets/a%2Fb", "", &status)
+	require.Nil(t, err)
+	assert.Equal(t, "a/b", status.ChainID)
+	_, err = callREST(t, http.MethodGet, "/v1/testnets/test%20net", "", &status)
+	require.Nil(t, err)
+	assert.Equal(t, "test net", status.ChainID)
+
+	// The escaped chain ID is one segment of the path
+	w, _ := callREST(t, http.MethodGet, "/v1/testnets/a/b", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	method, chainID := RESTMethod(httptest.NewRequest(http.MethodPost, "/v1/testnets/a%2Fb/registrations", nil))
+	assert.Equal(t, "register", method)
+	assert.Equal(t, "a/b", chainID)
+}
+
+func TestRESTBodyLimit(t *testing.T) {
+	setupTestnets(t, map[string]cfg.TestnetsTOMLConfig{"default": {RequiredValidators: 1}})
+	previous := config
+	defer SetConfig(previous)
+	limited := previous
+	limited.MaxBodyBytes = 64
+	SetConfig(limited)
+
+	w, err := callREST(t, http.MethodPost, "/v1/testnets/default/registrations",
+		`{"name": "`+strings.Repeat("a", 64)+`", "pub_key": "", "net_address": ""}`, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	require.NotNil(t, err)
+	assert.Equal(t, types.CodeParseError, err.Code)
+	assert.Contains(t, err.Message, "too large")
+}
+
+func TestHTTPStatus(t *testing.T) {
+	testCases := map[types.ErrorCode]int{
+		types.CodeInvalidParam:        http.StatusBadRequest,
+		types.CodeKeyTypeNotAccepted:  http.StatusBadRequest,
+		types.CodeUnregisteredTestnet: http.StatusNotFound,
+		types.CodeUnregisteredNode:    http.StatusNotFound,
+		types.CodeFileNotFound:        http.StatusNotFound,
+		types.CodeTestnetNotReady:     http.StatusConflict,
+		types.CodeRegistrationClosed:  http.StatusConflict,
+		types.CodeLimitReached:        http.StatusConflict,
+		types.CodeRegistrationLocked:  http.StatusConflict,
+		types.CodeInvalidStateChange:  http.StatusConflict,
+		types.CodeTooManyRequests:     http.StatusTooManyRequests,
+		types.CodeNotLeader:           http.StatusServiceUnavailable,
+		types.CodeInternalError:       http.StatusInternalServerError,
+	}
+	for code, status := range testCases {
+		assert.Equal(t, status, httpStatus(code), "code %d", code)
+	}
+}
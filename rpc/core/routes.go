@@ -1,13 +1,26 @@
 package core
 
 import (
-	rpc "github.com/tendermint/tendermint/rpc/lib/server"
+	rpc "director/m/v2/rpc/jsonrpc"
 )
 
 // Routes defines RPC endpoints
 var Routes = map[string]*rpc.RPCFunc{
 	// API
-	"register": rpc.NewRPCFunc(Register, "chain_id,name,pub_key,net_address"),
-	"genesis":  rpc.NewRPCFunc(Genesis, "chain_id"),
-	"addrbook": rpc.NewRPCFunc(AddressBook, "chain_id"),
+	"register":      newWriteRPCFunc(Register, "chain_id,name,pub_key,net_address,seed,key_type,account_address,gentx,power,role,sentries,private"),
+	"register_json": newWriteRPCFunc(RegisterJSON, "chain_id,name,priv_validator_key,node_key,host,port,seed,account_address,gentx,power,role,sentries,private"),
+	"genesis":       rpc.NewRPCFunc(Genesis, "chain_id"),
+	"addrbook":      rpc.NewRPCFunc(AddressBook, "chain_id"),
+	"peers":         rpc.NewRPCFunc(Peers, "chain_id,exclude,limit,seeds_only"),
+	"node_config":   rpc.NewRPCFunc(NodeConfig, "chain_id,pub_key,node_id"),
+	"status":        rpc.NewRPCFunc(Status, "chain_id"),
+	"testnets":      rpc.NewRPCFunc(Testnets, ""),
+}
+
+// AddUnsafeRoutes adds the administrative endpoints. They are only enabled with the `unsafe` option of the [rpc] section.
+func AddUnsafeRoutes() {
+	Routes["archive"] = newWriteRPCFunc(Archive, "chain_id")
+	Routes["reopen"] = newWriteRPCFunc(Reopen, "chain_id")
+	Routes["select_validator"] = newWriteRPCFunc(SelectValidator, "chain_id,pub_key,selected")
+	Routes["snapshot"] = rpc.NewRPCFunc(Snapshot, "")
 }
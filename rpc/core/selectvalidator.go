@@ -0,0 +1,18 @@
+package core
+
+import (
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// SelectValidator picks or drops a registered node for the genesis validator set of a testnet
+// with the admin selection policy. The genesis is compiled when enough nodes are selected.
+func SelectValidator(ctx *rpctypes.Context, chainID string, pubKey string, selected bool) (*ResultSelectValidator, error) {
+	if err := stateMachine.SelectValidator(chainID, pubKey, selected); err != nil {
+		return nil, err
+	}
+	return &ResultSelectValidator{
+		ChainID:  chainID,
+		PubKey:   pubKey,
+		Selected: selected,
+	}, nil
+}
@@ -0,0 +1,14 @@
+package core
+
+import (
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// Snapshot writes a snapshot of every testnet to the snapshot directory of the director.
+func Snapshot(ctx *rpctypes.Context) (*ResultSnapshot, error) {
+	path, err := stateMachine.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ResultSnapshot{Path: path}, nil
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+// Status returns the state and the registration progress of a testnet, including the outcome of its timeout
+func Status(ctx *rpctypes.Context, chainID string) (*ResultStatus, error) {
+	status, err := stateMachine.GetStatus(chainID)
+	if err != nil {
+		return nil, err
+	}
+	result := &ResultStatus{
+		ChainID:            chainID,
+		State:              status.State.String(),
+		Validators:         status.Validators,
+		RequiredValidators: status.RequiredValidators,
+		MinValidators:      status.MinValidators,
+		MaxValidators:      status.MaxValidators,
+		Standby:            status.Standby,
+		Nodes:              status.Nodes,
+		Extensions:         status.Extensions,
+		TimeoutOutcome:     status.TimeoutOutcome,
+	}
+	if !status.Deadline.IsZero() {
+		result.Deadline = &status.Deadline
+	}
+	return result, nil
+}
+
+// Testnets returns the status of every testnet, including the archived instances of recurring testnets
+func Testnets(ctx *rpctypes.Context) (*ResultTestnets, error) {
+	chainIDs := stateMachine.ListTestnets()
+	result := &ResultTestnets{Testnets: make([]*ResultStatus, 0, len(chainIDs))}
+	for _, chainID := range chainIDs {
+		status, err := Status(ctx, chainID)
+		if err != nil {
+			return nil, err
+		}
+		result.Testnets = append(result.Testnets, status)
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/p2p"
+	"time"
+)
+
+// ResultRegister is the outcome of a registration
+type ResultRegister struct {
+	ChainID string `json:"chain_id"`
+	NodeID  p2p.ID `json:"node_id"`
+	Role    string `json:"role"`
+}
+
+// ResultPeers is the peer list of a testnet
+type ResultPeers struct {
+	// Comma-separated `id@host:port` list, as used by the `seeds` and `persistent_peers` options
+	Peers string `json:"peers"`
+}
+
+// ResultNodeConfig is the Tendermint configuration of a registered node
+type ResultNodeConfig struct {
+	NodeID  p2p.ID `json:"node_id"`
+	Moniker string `json:"moniker"`
+	// Content of config.toml
+	Config string `json:"config"`
+}
+
+// PrivValidatorKeyJSON is the public part of Tendermint's priv_validator_key.json
+type PrivValidatorKeyJSON struct {
+	Address crypto.Address `json:"address"`
+	PubKey  crypto.PubKey  `json:"pub_key"`
+}
+
+// NodeKeyJSON is the public part of Tendermint's node_key.json.
+// node_key.json only holds the private key, so either its public key or the node ID is expected.
+type NodeKeyJSON struct {
+	ID     p2p.ID        `json:"id"`
+	PubKey crypto.PubKey `json:"pub_key"`
+}
+
+// ResultArchive is the outcome of archiving a testnet
+type ResultArchive struct {
+	ChainID string `json:"chain_id"`
+	// Chain ID of the instance opened after the archived one, if the testnet is recurring
+	NextChainID string `json:"next_chain_id,omitempty"`
+}
+
+// ResultSnapshot is the snapshot file written by the snapshot endpoint
+type ResultSnapshot struct {
+	Path string `json:"path"`
+}
+
+// ResultStatus is the registration progress of a testnet
+type ResultStatus struct {
+	ChainID            string `json:"chain_id"`
+	State              string `json:"state"`
+	Validators         int    `json:"validators"`
+	RequiredValidators uint   `json:"required_validators"`
+	MinValidators      uint   `json:"min_validators"`
+	MaxValidators      uint   `json:"max_validators"`
+	// Number of waitlisted registrations, or standby nodes once the genesis is compiled
+	Standby int `json:"standby"`
+	// Number of sentry, seed and full node registrations
+	Nodes int `json:"nodes"`
+	// End of the registration period, omitted if the testnet has no timeout
+	Deadline       *time.Time `json:"deadline,omitempty"`
+	Extensions     int        `json:"extensions"`
+	TimeoutOutcome string     `json:"timeout_outcome,omitempty"`
+}
+
+// ResultTestnets is the status of every testnet
+type ResultTestnets struct {
+	Testnets []*ResultStatus `json:"testnets"`
+}
+
+// ResultReopen is the outcome of reopening a failed testnet
+type ResultReopen struct {
+	ChainID string `json:"chain_id"`
+	State   string `json:"state"`
+}
+
+// ResultSelectValidator is the outcome of an admin selection
+type ResultSelectValidator struct {
+	ChainID  string `json:"chain_id"`
+	PubKey   string `json:"pub_key"`
+	Selected bool   `json:"selected"`
+}
@@ -0,0 +1,151 @@
+package coregrpc
+
+import (
+	"context"
+	"director/m/v2/rpc/core"
+	"director/m/v2/types"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"time"
+)
+
+// directorAPI implements DirectorAPIServer with the handlers of the JSON-RPC API
+type directorAPI struct {
+	leadership    core.Leadership
+	proxyWrites   bool
+	rateLimiter   RateLimiter
+	watchInterval time.Duration
+}
+
+var _ DirectorAPIServer = (*directorAPI)(nil)
+
+// Register registers a node. Followers forward or reject it before it gets here, see unaryInterceptor.
+func (api *directorAPI) Register(ctx context.Context, req *RequestRegister) (*ResponseRegister, error) {
+	res, err := core.Register(&rpctypes.Context{}, req.ChainId, req.Name, req.PubKey, req.NetAddress, req.Seed, req.KeyType,
+		req.AccountAddress, req.Gentx, req.Power, req.Role, strings.Join(req.Sentries, ","), req.Private)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ResponseRegister{
+		ChainId: res.ChainID,
+		NodeId:  string(res.NodeID),
+		Role:    res.Role,
+	}, nil
+}
+
+func (api *directorAPI) GetGenesis(ctx context.Context, req *RequestGenesis) (*ResponseGenesis, error) {
+	content, err := core.GenesisFile(req.ChainId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ResponseGenesis{Genesis: content}, nil
+}
+
+func (api *directorAPI) GetAddressBook(ctx context.Context, req *RequestAddressBook) (*ResponseAddressBook, error) {
+	content, err := core.AddressBookFile(req.ChainId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ResponseAddressBook{AddressBook: content}, nil
+}
+
+func (api *directorAPI) GetStatus(ctx context.Context, req *RequestStatus) (*ResponseStatus, error) {
+	res, err := core.Status(&rpctypes.Context{}, req.ChainId)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toResponseStatus(res)
+}
+
+// WatchTestnet polls the status of the testnet every watchInterval and sends it when it changed
+func (api *directorAPI) WatchTestnet(req *RequestWatchTestnet, stream DirectorAPI_WatchTestnetServer) error {
+	ticker := time.NewTicker(api.watchInterval)
+	defer ticker.Stop()
+
+	var last *ResponseStatus
+	for {
+		res, err := api.GetStatus(stream.Context(), &RequestStatus{ChainId: req.ChainId})
+		if err != nil {
+			return err
+		}
+		if last == nil || !proto.Equal(res, last) {
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			last = res
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func toResponseStatus(res *core.ResultStatus) (*ResponseStatus, error) {
+	response := &ResponseStatus{
+		ChainId:            res.ChainID,
+		State:              res.State,
+		Validators:         int32(res.Validators),
+		RequiredValidators: uint32(res.RequiredValidators),
+		MinValidators:      uint32(res.MinValidators),
+		MaxValidators:      uint32(res.MaxValidators),
+		Standby:            int32(res.Standby),
+		Nodes:              int32(res.Nodes),
+		Extensions:         int32(res.Extensions),
+		TimeoutOutcome:     res.TimeoutOutcome,
+	}
+	if res.Deadline != nil {
+		deadline, err := ptypes.TimestampProto(*res.Deadline)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		response.Deadline = deadline
+	}
+	return response, nil
+}
+
+// toStatusError converts the typed errors of the store and the RPC layer to a gRPC status with an Error detail
+func toStatusError(err error) error {
+	e, ok := types.AsError(err)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+	st, detailErr := status.New(statusCode(e.Code), e.Message).WithDetails(&Error{
+		Code:    int32(e.Code),
+		Message: e.Message,
+		ChainId: e.Data.ChainID,
+		State:   e.Data.State,
+		Limit:   e.Data.Limit,
+		Param:   e.Data.Param,
+		Leader:  e.Data.Leader,
+	})
+	if detailErr != nil {
+		return status.Error(statusCode(e.Code), e.Message)
+	}
+	return st.Err()
+}
+
+// statusCode maps the code of a typed error to a gRPC code
+func statusCode(code types.ErrorCode) codes.Code {
+	switch code {
+	case types.CodeInvalidParam, types.CodeKeyTypeNotAccepted:
+		return codes.InvalidArgument
+	case types.CodeUnregisteredTestnet, types.CodeUnregisteredNode, types.CodeFileNotFound:
+		return codes.NotFound
+	case types.CodeLimitReached, types.CodeTooManyRequests:
+		return codes.ResourceExhausted
+	case types.CodeTestnetNotReady, types.CodeRegistrationClosed, types.CodeRegistrationLocked,
+		types.CodeInvalidStateChange:
+		return codes.FailedPrecondition
+	case types.CodeNotLeader:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,67 @@
+package coregrpc
+
+import (
+	"context"
+	"director/m/v2/rpc/core"
+	tmnet "github.com/tendermint/tendermint/libs/net"
+	"google.golang.org/grpc"
+	"net"
+	"time"
+)
+
+// DefaultWatchInterval is how often WatchTestnet checks a testnet for changes by default
+const DefaultWatchInterval = time.Second
+
+// Config is a gRPC server configuration.
+type Config struct {
+	// Leadership makes followers forward or reject registrations. Nil if the director does not take part in an election.
+	Leadership core.Leadership
+	// ProxyWrites makes followers forward registrations to the JSON-RPC API of the leader instead of rejecting them
+	ProxyWrites bool
+	// RateLimiter throttles the calls with the limits of the JSON-RPC API. Nil disables rate limiting.
+	RateLimiter RateLimiter
+	// WatchInterval is how often WatchTestnet checks a testnet for changes
+	WatchInterval time.Duration
+}
+
+// StartGRPCServer starts a new gRPC DirectorAPIServer using the given
+// net.Listener.
+// NOTE: This function blocks - you may want to call it in a go-routine.
+func StartGRPCServer(ln net.Listener, config Config) error {
+	if config.WatchInterval <= 0 {
+		config.WatchInterval = DefaultWatchInterval
+	}
+	api := &directorAPI{
+		leadership:    config.Leadership,
+		proxyWrites:   config.ProxyWrites,
+		rateLimiter:   config.RateLimiter,
+		watchInterval: config.WatchInterval,
+	}
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(api.unaryInterceptor),
+		grpc.StreamInterceptor(api.streamInterceptor),
+	)
+	RegisterDirectorAPIServer(grpcServer, api)
+	return grpcServer.Serve(ln)
+}
+
+// RateLimiter checks the limits of the calls of a client, see middleware.RateLimiter
+type RateLimiter interface {
+	// Allow returns how long to wait and the reason if a call of the JSON-RPC method on chainID is rejected,
+	// and an empty reason if it is allowed
+	Allow(ip string, method string, chainID string) (time.Duration, string)
+}
+
+// StartGRPCClient dials the gRPC server using protoAddr and returns a new
+// DirectorAPIClient.
+func StartGRPCClient(protoAddr string) (DirectorAPIClient, error) {
+	conn, err := grpc.Dial(protoAddr, grpc.WithInsecure(), grpc.WithContextDialer(dialerFunc))
+	if err != nil {
+		return nil, err
+	}
+	return NewDirectorAPIClient(conn), nil
+}
+
+func dialerFunc(ctx context.Context, addr string) (net.Conn, error) {
+	return tmnet.Connect(addr)
+}
@@ -0,0 +1,127 @@
+package coregrpc
+
+import (
+	"context"
+	"director/m/v2/client"
+	"director/m/v2/rpc/core"
+	"director/m/v2/types"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"math"
+	"net"
+	"strconv"
+)
+
+// rpcMethods maps the gRPC methods to the JSON-RPC methods with the same effect,
+// so both APIs share the rate limits and the leader policy
+var rpcMethods = map[string]string{
+	"/director.rpc.grpc.DirectorAPI/Register":       "register",
+	"/director.rpc.grpc.DirectorAPI/GetGenesis":     "genesis",
+	"/director.rpc.grpc.DirectorAPI/GetAddressBook": "addrbook",
+	"/director.rpc.grpc.DirectorAPI/GetStatus":      "status",
+	"/director.rpc.grpc.DirectorAPI/WatchTestnet":   "status",
+}
+
+// unaryInterceptor applies the rate limits to every call, and forwards or rejects the writes sent to a follower
+func (api *directorAPI) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := rpcMethods[info.FullMethod]
+	var chainID string
+	if r, ok := req.(interface{ GetChainId() string }); ok {
+		chainID = r.GetChainId()
+	}
+	if err := api.checkLimits(ctx, method, chainID, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+		return nil, err
+	}
+
+	if core.WriteMethods[method] {
+		if err := core.CheckLeader(api.leadership); err != nil {
+			if e, ok := types.AsError(err); ok && api.proxyWrites && e.Data.Leader != "" {
+				return api.forward(ctx, e.Data.Leader, req)
+			}
+			return nil, toStatusError(err)
+		}
+	}
+	return handler(ctx, req)
+}
+
+// streamInterceptor applies the rate limits to the streams. Streams don't change the store.
+func (api *directorAPI) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := api.checkLimits(ss.Context(), rpcMethods[info.FullMethod], "", ss.SetHeader); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// checkLimits rejects the calls of throttled or banned clients. setHeader sends the retry-after header of the rejection.
+func (api *directorAPI) checkLimits(ctx context.Context, method string, chainID string, setHeader func(metadata.MD) error) error {
+	if api.rateLimiter == nil {
+		return nil
+	}
+	retryAfter, reason := api.rateLimiter.Allow(remoteIP(ctx), method, chainID)
+	if reason == "" {
+		return nil
+	}
+	_ = setHeader(metadata.Pairs("retry-after", strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))))
+	return toStatusError(types.NewError(types.CodeTooManyRequests, "%s", reason))
+}
+
+// forward sends a write to the JSON-RPC API of the leader at leader, the same way followers proxy HTTP writes
+func (api *directorAPI) forward(ctx context.Context, leader string, req interface{}) (interface{}, error) {
+	c, err := client.New(leader)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	switch req := req.(type) {
+	case *RequestRegister:
+		res, err := c.Register(ctx, req.ChainId, client.Registration{
+			Name:           req.Name,
+			PubKey:         req.PubKey,
+			KeyType:        req.KeyType,
+			NetAddress:     req.NetAddress,
+			Seed:           req.Seed,
+			AccountAddress: req.AccountAddress,
+			GenTx:          json.RawMessage(req.Gentx),
+			Power:          req.Power,
+			Role:           req.Role,
+			Sentries:       req.Sentries,
+			Private:        req.Private,
+		})
+		if err != nil {
+			return nil, forwardError(err)
+		}
+		return &ResponseRegister{
+			ChainId: res.ChainID,
+			NodeId:  string(res.NodeID),
+			Role:    res.Role,
+		}, nil
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "can't forward %T to the leader", req)
+	}
+}
+
+// forwardError converts the error of a forwarded write. Errors returned by the leader keep their code.
+func forwardError(err error) error {
+	var e *client.Error
+	if errors.As(err, &e) {
+		return toStatusError(&types.Error{Code: e.Code, Message: e.Message, Data: e.Data})
+	}
+	return status.Errorf(codes.Unavailable, "failed to forward the write to the leader: %v", err)
+}
+
+// remoteIP returns the IP of the client of a call
+func remoteIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,128 @@
+package coregrpc
+
+import (
+	"context"
+	cfg "director/m/v2/config"
+	"director/m/v2/rpc/middleware"
+	"director/m/v2/types"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/log"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// follower is the leadership of a director following leader
+type follower struct {
+	leader string
+}
+
+func (f *follower) IsLeader() bool {
+	return false
+}
+
+func (f *follower) Leader() (string, error) {
+	return f.leader, nil
+}
+
+// startTestServer serves the gRPC API with config on a random port
+func startTestServer(t *testing.T, config Config) (DirectorAPIClient, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go StartGRPCServer(listener, config) // nolint: errcheck
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	return NewDirectorAPIClient(conn), func() {
+		conn.Close()     // nolint: errcheck
+		listener.Close() // nolint: errcheck
+	}
+}
+
+// requireErrorCode checks the gRPC code and the code of the Error detail of err
+func requireErrorCode(t *testing.T, err error, grpcCode codes.Code, code types.ErrorCode) *Error {
+	st, ok := status.FromError(err)
+	require.True(t, ok, "not a gRPC status: %v", err)
+	require.Equal(t, grpcCode, st.Code(), st.Message())
+	require.Len(t, st.Details(), 1)
+	detail, ok := st.Details()[0].(*Error)
+	require.True(t, ok)
+	require.Equal(t, int32(code), detail.Code)
+	return detail
+}
+
+func TestFollowerRejectsRegistrations(t *testing.T) {
+	api, stop := startTestServer(t, Config{Leadership: &follower{leader: "http://leader:27001"}})
+	defer stop()
+
+	_, err := api.Register(context.Background(), &RequestRegister{ChainId: "default"})
+	detail := requireErrorCode(t, err, codes.Unavailable, types.CodeNotLeader)
+	assert.Contains(t, detail.Message, "http://leader:27001")
+	assert.Equal(t, "http://leader:27001", detail.Leader)
+}
+
+func TestFollowerForwardsRegistrations(t *testing.T) {
+	var request rpctypes.RPCRequest
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		var params map[string]interface{}
+		assert.NoError(t, json.Unmarshal(request.Params, &params))
+		w.Header().Set("Content-Type", "application/json")
+		if params["chain_id"] == "closed" {
+			w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "error": {"code": 1003, "message": "testnet not accepting new registrations", "data": {"chain_id": "closed", "state": "archived"}}}`)) // nolint: errcheck
+			return
+		}
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"chain_id": "default", "node_id": "abcd", "role": "validator"}}`)) // nolint: errcheck
+	}))
+	defer leader.Close()
+	api, stop := startTestServer(t, Config{Leadership: &follower{leader: leader.URL}, ProxyWrites: true})
+	defer stop()
+
+	res, err := api.Register(context.Background(), &RequestRegister{ChainId: "default", Name: "validator1", Sentries: []string{"a", "b"}})
+	require.NoError(t, err)
+	assert.Equal(t, &ResponseRegister{ChainId: "default", NodeId: "abcd", Role: "validator"}, res)
+	assert.Equal(t, "register", request.Method)
+	assert.Contains(t, string(request.Params), `"name":"validator1"`)
+	assert.Contains(t, string(request.Params), `"sentries":"a,b"`)
+
+	// Errors of the leader keep their code
+	_, err = api.Register(context.Background(), &RequestRegister{ChainId: "closed"})
+	detail := requireErrorCode(t, err, codes.FailedPrecondition, types.CodeRegistrationClosed)
+	assert.Equal(t, "archived", detail.State)
+}
+
+func TestRateLimits(t *testing.T) {
+	config := cfg.DefaultRPCConfig()
+	config.RateLimitPerIP = 0.001
+	config.RateLimitPerIPBurst = 1
+	limiter := middleware.NewRateLimiter(config, log.NewNopLogger())
+	api, stop := startTestServer(t, Config{Leadership: &follower{}, RateLimiter: limiter})
+	defer stop()
+
+	// The first call gets through the limiter to the leader policy
+	_, err := api.Register(context.Background(), &RequestRegister{ChainId: "default"})
+	requireErrorCode(t, err, codes.Unavailable, types.CodeNotLeader)
+
+	var header metadata.MD
+	_, err = api.Register(context.Background(), &RequestRegister{ChainId: "default"}, grpc.Header(&header))
+	requireErrorCode(t, err, codes.ResourceExhausted, types.CodeTooManyRequests)
+	assert.NotEmpty(t, header.Get("retry-after"))
+
+	// Streams share the limits
+	stream, err := api.WatchTestnet(context.Background(), &RequestWatchTestnet{ChainId: "default"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	requireErrorCode(t, err, codes.ResourceExhausted, types.CodeTooManyRequests)
+
+	// Other clients are not throttled
+	_, reason := limiter.Allow("10.0.0.1", "status", "")
+	assert.Empty(t, reason)
+}
@@ -0,0 +1,981 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: rpc/grpc/types.proto
+
+package coregrpc
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// RequestRegister registers a node. The fields are the parameters of the register JSON-RPC method.
+type RequestRegister struct {
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// Base64 public key of the validator
+	PubKey  string `protobuf:"bytes,3,opt,name=pub_key,json=pubKey,proto3" json:"pub_key,omitempty"`
+	KeyType string `protobuf:"bytes,4,opt,name=key_type,json=keyType,proto3" json:"key_type,omitempty"`
+	// id@host:port address of the node
+	NetAddress     string `protobuf:"bytes,5,opt,name=net_address,json=netAddress,proto3" json:"net_address,omitempty"`
+	Seed           bool   `protobuf:"varint,6,opt,name=seed,proto3" json:"seed,omitempty"`
+	AccountAddress string `protobuf:"bytes,7,opt,name=account_address,json=accountAddress,proto3" json:"account_address,omitempty"`
+	Gentx          string `protobuf:"bytes,8,opt,name=gentx,proto3" json:"gentx,omitempty"`
+	Power          int64  `protobuf:"varint,9,opt,name=power,proto3" json:"power,omitempty"`
+	Role           string `protobuf:"bytes,10,opt,name=role,proto3" json:"role,omitempty"`
+	// Node IDs of the sentries of a private validator
+	Sentries             []string `protobuf:"bytes,11,rep,name=sentries,proto3" json:"sentries,omitempty"`
+	Private              bool     `protobuf:"varint,12,opt,name=private,proto3" json:"private,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestRegister) Reset()         { *m = RequestRegister{} }
+func (m *RequestRegister) String() string { return proto.CompactTextString(m) }
+func (*RequestRegister) ProtoMessage()    {}
+func (*RequestRegister) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{0}
+}
+
+func (m *RequestRegister) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestRegister.Unmarshal(m, b)
+}
+func (m *RequestRegister) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestRegister.Marshal(b, m, deterministic)
+}
+func (m *RequestRegister) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestRegister.Merge(m, src)
+}
+func (m *RequestRegister) XXX_Size() int {
+	return xxx_messageInfo_RequestRegister.Size(m)
+}
+func (m *RequestRegister) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestRegister.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestRegister proto.InternalMessageInfo
+
+func (m *RequestRegister) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetPubKey() string {
+	if m != nil {
+		return m.PubKey
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetKeyType() string {
+	if m != nil {
+		return m.KeyType
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetNetAddress() string {
+	if m != nil {
+		return m.NetAddress
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetSeed() bool {
+	if m != nil {
+		return m.Seed
+	}
+	return false
+}
+
+func (m *RequestRegister) GetAccountAddress() string {
+	if m != nil {
+		return m.AccountAddress
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetGentx() string {
+	if m != nil {
+		return m.Gentx
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetPower() int64 {
+	if m != nil {
+		return m.Power
+	}
+	return 0
+}
+
+func (m *RequestRegister) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *RequestRegister) GetSentries() []string {
+	if m != nil {
+		return m.Sentries
+	}
+	return nil
+}
+
+func (m *RequestRegister) GetPrivate() bool {
+	if m != nil {
+		return m.Private
+	}
+	return false
+}
+
+type RequestGenesis struct {
+	ChainId              string   `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestGenesis) Reset()         { *m = RequestGenesis{} }
+func (m *RequestGenesis) String() string { return proto.CompactTextString(m) }
+func (*RequestGenesis) ProtoMessage()    {}
+func (*RequestGenesis) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{1}
+}
+
+func (m *RequestGenesis) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestGenesis.Unmarshal(m, b)
+}
+func (m *RequestGenesis) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestGenesis.Marshal(b, m, deterministic)
+}
+func (m *RequestGenesis) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestGenesis.Merge(m, src)
+}
+func (m *RequestGenesis) XXX_Size() int {
+	return xxx_messageInfo_RequestGenesis.Size(m)
+}
+func (m *RequestGenesis) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestGenesis.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestGenesis proto.InternalMessageInfo
+
+func (m *RequestGenesis) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+type RequestAddressBook struct {
+	ChainId              string   `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestAddressBook) Reset()         { *m = RequestAddressBook{} }
+func (m *RequestAddressBook) String() string { return proto.CompactTextString(m) }
+func (*RequestAddressBook) ProtoMessage()    {}
+func (*RequestAddressBook) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{2}
+}
+
+func (m *RequestAddressBook) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestAddressBook.Unmarshal(m, b)
+}
+func (m *RequestAddressBook) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestAddressBook.Marshal(b, m, deterministic)
+}
+func (m *RequestAddressBook) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestAddressBook.Merge(m, src)
+}
+func (m *RequestAddressBook) XXX_Size() int {
+	return xxx_messageInfo_RequestAddressBook.Size(m)
+}
+func (m *RequestAddressBook) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestAddressBook.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestAddressBook proto.InternalMessageInfo
+
+func (m *RequestAddressBook) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+type RequestStatus struct {
+	ChainId              string   `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestStatus) Reset()         { *m = RequestStatus{} }
+func (m *RequestStatus) String() string { return proto.CompactTextString(m) }
+func (*RequestStatus) ProtoMessage()    {}
+func (*RequestStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{3}
+}
+
+func (m *RequestStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestStatus.Unmarshal(m, b)
+}
+func (m *RequestStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestStatus.Marshal(b, m, deterministic)
+}
+func (m *RequestStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestStatus.Merge(m, src)
+}
+func (m *RequestStatus) XXX_Size() int {
+	return xxx_messageInfo_RequestStatus.Size(m)
+}
+func (m *RequestStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestStatus proto.InternalMessageInfo
+
+func (m *RequestStatus) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+type RequestWatchTestnet struct {
+	ChainId              string   `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequestWatchTestnet) Reset()         { *m = RequestWatchTestnet{} }
+func (m *RequestWatchTestnet) String() string { return proto.CompactTextString(m) }
+func (*RequestWatchTestnet) ProtoMessage()    {}
+func (*RequestWatchTestnet) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{4}
+}
+
+func (m *RequestWatchTestnet) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequestWatchTestnet.Unmarshal(m, b)
+}
+func (m *RequestWatchTestnet) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequestWatchTestnet.Marshal(b, m, deterministic)
+}
+func (m *RequestWatchTestnet) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequestWatchTestnet.Merge(m, src)
+}
+func (m *RequestWatchTestnet) XXX_Size() int {
+	return xxx_messageInfo_RequestWatchTestnet.Size(m)
+}
+func (m *RequestWatchTestnet) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequestWatchTestnet.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequestWatchTestnet proto.InternalMessageInfo
+
+func (m *RequestWatchTestnet) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+type ResponseRegister struct {
+	ChainId              string   `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	NodeId               string   `protobuf:"bytes,2,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Role                 string   `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResponseRegister) Reset()         { *m = ResponseRegister{} }
+func (m *ResponseRegister) String() string { return proto.CompactTextString(m) }
+func (*ResponseRegister) ProtoMessage()    {}
+func (*ResponseRegister) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{5}
+}
+
+func (m *ResponseRegister) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResponseRegister.Unmarshal(m, b)
+}
+func (m *ResponseRegister) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResponseRegister.Marshal(b, m, deterministic)
+}
+func (m *ResponseRegister) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResponseRegister.Merge(m, src)
+}
+func (m *ResponseRegister) XXX_Size() int {
+	return xxx_messageInfo_ResponseRegister.Size(m)
+}
+func (m *ResponseRegister) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResponseRegister.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResponseRegister proto.InternalMessageInfo
+
+func (m *ResponseRegister) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *ResponseRegister) GetNodeId() string {
+	if m != nil {
+		return m.NodeId
+	}
+	return ""
+}
+
+func (m *ResponseRegister) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+type ResponseGenesis struct {
+	// Content of genesis.json
+	Genesis              []byte   `protobuf:"bytes,1,opt,name=genesis,proto3" json:"genesis,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResponseGenesis) Reset()         { *m = ResponseGenesis{} }
+func (m *ResponseGenesis) String() string { return proto.CompactTextString(m) }
+func (*ResponseGenesis) ProtoMessage()    {}
+func (*ResponseGenesis) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{6}
+}
+
+func (m *ResponseGenesis) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResponseGenesis.Unmarshal(m, b)
+}
+func (m *ResponseGenesis) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResponseGenesis.Marshal(b, m, deterministic)
+}
+func (m *ResponseGenesis) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResponseGenesis.Merge(m, src)
+}
+func (m *ResponseGenesis) XXX_Size() int {
+	return xxx_messageInfo_ResponseGenesis.Size(m)
+}
+func (m *ResponseGenesis) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResponseGenesis.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResponseGenesis proto.InternalMessageInfo
+
+func (m *ResponseGenesis) GetGenesis() []byte {
+	if m != nil {
+		return m.Genesis
+	}
+	return nil
+}
+
+type ResponseAddressBook struct {
+	// Content of addrbook.json
+	AddressBook          []byte   `protobuf:"bytes,1,opt,name=address_book,json=addressBook,proto3" json:"address_book,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResponseAddressBook) Reset()         { *m = ResponseAddressBook{} }
+func (m *ResponseAddressBook) String() string { return proto.CompactTextString(m) }
+func (*ResponseAddressBook) ProtoMessage()    {}
+func (*ResponseAddressBook) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{7}
+}
+
+func (m *ResponseAddressBook) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResponseAddressBook.Unmarshal(m, b)
+}
+func (m *ResponseAddressBook) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResponseAddressBook.Marshal(b, m, deterministic)
+}
+func (m *ResponseAddressBook) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResponseAddressBook.Merge(m, src)
+}
+func (m *ResponseAddressBook) XXX_Size() int {
+	return xxx_messageInfo_ResponseAddressBook.Size(m)
+}
+func (m *ResponseAddressBook) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResponseAddressBook.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResponseAddressBook proto.InternalMessageInfo
+
+func (m *ResponseAddressBook) GetAddressBook() []byte {
+	if m != nil {
+		return m.AddressBook
+	}
+	return nil
+}
+
+// ResponseStatus is the state and the registration progress of a testnet
+type ResponseStatus struct {
+	ChainId            string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	State              string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	Validators         int32  `protobuf:"varint,3,opt,name=validators,proto3" json:"validators,omitempty"`
+	RequiredValidators uint32 `protobuf:"varint,4,opt,name=required_validators,json=requiredValidators,proto3" json:"required_validators,omitempty"`
+	MinValidators      uint32 `protobuf:"varint,5,opt,name=min_validators,json=minValidators,proto3" json:"min_validators,omitempty"`
+	MaxValidators      uint32 `protobuf:"varint,6,opt,name=max_validators,json=maxValidators,proto3" json:"max_validators,omitempty"`
+	// Number of waitlisted registrations, or standby nodes once the genesis is compiled
+	Standby int32 `protobuf:"varint,7,opt,name=standby,proto3" json:"standby,omitempty"`
+	// Number of sentry, seed and full node registrations
+	Nodes int32 `protobuf:"varint,8,opt,name=nodes,proto3" json:"nodes,omitempty"`
+	// End of the registration period, unset if the testnet has no timeout
+	Deadline             *timestamp.Timestamp `protobuf:"bytes,9,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	Extensions           int32                `protobuf:"varint,10,opt,name=extensions,proto3" json:"extensions,omitempty"`
+	TimeoutOutcome       string               `protobuf:"bytes,11,opt,name=timeout_outcome,json=timeoutOutcome,proto3" json:"timeout_outcome,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *ResponseStatus) Reset()         { *m = ResponseStatus{} }
+func (m *ResponseStatus) String() string { return proto.CompactTextString(m) }
+func (*ResponseStatus) ProtoMessage()    {}
+func (*ResponseStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{8}
+}
+
+func (m *ResponseStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResponseStatus.Unmarshal(m, b)
+}
+func (m *ResponseStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResponseStatus.Marshal(b, m, deterministic)
+}
+func (m *ResponseStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResponseStatus.Merge(m, src)
+}
+func (m *ResponseStatus) XXX_Size() int {
+	return xxx_messageInfo_ResponseStatus.Size(m)
+}
+func (m *ResponseStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResponseStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResponseStatus proto.InternalMessageInfo
+
+func (m *ResponseStatus) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *ResponseStatus) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *ResponseStatus) GetValidators() int32 {
+	if m != nil {
+		return m.Validators
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetRequiredValidators() uint32 {
+	if m != nil {
+		return m.RequiredValidators
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetMinValidators() uint32 {
+	if m != nil {
+		return m.MinValidators
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetMaxValidators() uint32 {
+	if m != nil {
+		return m.MaxValidators
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetStandby() int32 {
+	if m != nil {
+		return m.Standby
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetNodes() int32 {
+	if m != nil {
+		return m.Nodes
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetDeadline() *timestamp.Timestamp {
+	if m != nil {
+		return m.Deadline
+	}
+	return nil
+}
+
+func (m *ResponseStatus) GetExtensions() int32 {
+	if m != nil {
+		return m.Extensions
+	}
+	return 0
+}
+
+func (m *ResponseStatus) GetTimeoutOutcome() string {
+	if m != nil {
+		return m.TimeoutOutcome
+	}
+	return ""
+}
+
+// Error is attached to the status of failed calls. The codes are the same as in the JSON-RPC API.
+type Error struct {
+	Code                 int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	ChainId              string   `protobuf:"bytes,3,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	State                string   `protobuf:"bytes,4,opt,name=state,proto3" json:"state,omitempty"`
+	Limit                string   `protobuf:"bytes,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Param                string   `protobuf:"bytes,6,opt,name=param,proto3" json:"param,omitempty"`
+	Leader               string   `protobuf:"bytes,7,opt,name=leader,proto3" json:"leader,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Error) Reset()         { *m = Error{} }
+func (m *Error) String() string { return proto.CompactTextString(m) }
+func (*Error) ProtoMessage()    {}
+func (*Error) Descriptor() ([]byte, []int) {
+	return fileDescriptor_15f63baabf91876a, []int{9}
+}
+
+func (m *Error) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Error.Unmarshal(m, b)
+}
+func (m *Error) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Error.Marshal(b, m, deterministic)
+}
+func (m *Error) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Error.Merge(m, src)
+}
+func (m *Error) XXX_Size() int {
+	return xxx_messageInfo_Error.Size(m)
+}
+func (m *Error) XXX_DiscardUnknown() {
+	xxx_messageInfo_Error.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Error proto.InternalMessageInfo
+
+func (m *Error) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *Error) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *Error) GetChainId() string {
+	if m != nil {
+		return m.ChainId
+	}
+	return ""
+}
+
+func (m *Error) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Error) GetLimit() string {
+	if m != nil {
+		return m.Limit
+	}
+	return ""
+}
+
+func (m *Error) GetParam() string {
+	if m != nil {
+		return m.Param
+	}
+	return ""
+}
+
+func (m *Error) GetLeader() string {
+	if m != nil {
+		return m.Leader
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*RequestRegister)(nil), "director.rpc.grpc.RequestRegister")
+	proto.RegisterType((*RequestGenesis)(nil), "director.rpc.grpc.RequestGenesis")
+	proto.RegisterType((*RequestAddressBook)(nil), "director.rpc.grpc.RequestAddressBook")
+	proto.RegisterType((*RequestStatus)(nil), "director.rpc.grpc.RequestStatus")
+	proto.RegisterType((*RequestWatchTestnet)(nil), "director.rpc.grpc.RequestWatchTestnet")
+	proto.RegisterType((*ResponseRegister)(nil), "director.rpc.grpc.ResponseRegister")
+	proto.RegisterType((*ResponseGenesis)(nil), "director.rpc.grpc.ResponseGenesis")
+	proto.RegisterType((*ResponseAddressBook)(nil), "director.rpc.grpc.ResponseAddressBook")
+	proto.RegisterType((*ResponseStatus)(nil), "director.rpc.grpc.ResponseStatus")
+	proto.RegisterType((*Error)(nil), "director.rpc.grpc.Error")
+}
+
+func init() { proto.RegisterFile("rpc/grpc/types.proto", fileDescriptor_15f63baabf91876a) }
+
+var fileDescriptor_15f63baabf91876a = []byte{
+	// 781 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xdd, 0x6e, 0x23, 0x35,
+	0x14, 0x56, 0x36, 0x9d, 0xfc, 0x9c, 0xb4, 0x29, 0xb8, 0x15, 0x3b, 0xe4, 0x82, 0xa6, 0x83, 0x76,
+	0xa9, 0x58, 0x29, 0x59, 0x15, 0x09, 0x21, 0x71, 0xb5, 0x2b, 0x50, 0xb5, 0xe2, 0x82, 0x95, 0x5b,
+	0x81, 0xb4, 0x5c, 0x8c, 0x9c, 0x99, 0x43, 0xd6, 0x4a, 0xc6, 0x9e, 0xb5, 0x3d, 0xa5, 0x79, 0x0c,
+	0x5e, 0x83, 0x17, 0x81, 0xc7, 0x42, 0xfe, 0x6b, 0xa7, 0x85, 0x64, 0x7b, 0x13, 0xf9, 0x3b, 0xfe,
+	0xce, 0x37, 0xf6, 0x39, 0xc7, 0x5f, 0xe0, 0x58, 0xd5, 0xc5, 0x7c, 0x69, 0x7f, 0xcc, 0xa6, 0x46,
+	0x3d, 0xab, 0x95, 0x34, 0x92, 0x7c, 0x5a, 0x72, 0x85, 0x85, 0x91, 0x6a, 0xa6, 0xea, 0x62, 0x66,
+	0xb7, 0x27, 0x27, 0x4b, 0x29, 0x97, 0x6b, 0x9c, 0x3b, 0xc2, 0xa2, 0xf9, 0x7d, 0x6e, 0x78, 0x85,
+	0xda, 0xb0, 0xaa, 0xf6, 0x39, 0xd9, 0x3f, 0x4f, 0xe0, 0x90, 0xe2, 0x87, 0x06, 0xb5, 0xa1, 0xb8,
+	0xe4, 0xda, 0xa0, 0x22, 0x9f, 0xc3, 0xa0, 0x78, 0xcf, 0xb8, 0xc8, 0x79, 0x99, 0x76, 0xa6, 0x9d,
+	0xb3, 0x21, 0xed, 0x3b, 0xfc, 0xa6, 0x24, 0x04, 0xf6, 0x04, 0xab, 0x30, 0x7d, 0xe2, 0xc2, 0x6e,
+	0x4d, 0x9e, 0x42, 0xbf, 0x6e, 0x16, 0xf9, 0x0a, 0x37, 0x69, 0xd7, 0x85, 0x7b, 0x75, 0xb3, 0xf8,
+	0x09, 0x37, 0x56, 0x67, 0x85, 0x9b, 0xdc, 0x1e, 0x31, 0xdd, 0xf3, 0x3a, 0x2b, 0xdc, 0x5c, 0x6d,
+	0x6a, 0x24, 0x27, 0x30, 0x12, 0x68, 0x72, 0x56, 0x96, 0x0a, 0xb5, 0x4e, 0x13, 0xb7, 0x0b, 0x02,
+	0xcd, 0x2b, 0x1f, 0xb1, 0x1f, 0xd2, 0x88, 0x65, 0xda, 0x9b, 0x76, 0xce, 0x06, 0xd4, 0xad, 0xc9,
+	0x57, 0x70, 0xc8, 0x8a, 0x42, 0x36, 0xe2, 0x2e, 0xb1, 0xef, 0x12, 0xc7, 0x21, 0x1c, 0x93, 0x8f,
+	0x21, 0x59, 0xa2, 0x30, 0x37, 0xe9, 0xc0, 0x6d, 0x7b, 0x60, 0xa3, 0xb5, 0xfc, 0x03, 0x55, 0x3a,
+	0x9c, 0x76, 0xce, 0xba, 0xd4, 0x03, 0xfb, 0x21, 0x25, 0xd7, 0x98, 0x82, 0xbf, 0x91, 0x5d, 0x93,
+	0x09, 0x0c, 0x34, 0x0a, 0xa3, 0x38, 0xea, 0x74, 0x34, 0xed, 0x9e, 0x0d, 0xe9, 0x2d, 0x26, 0x29,
+	0xf4, 0x6b, 0xc5, 0xaf, 0x99, 0xc1, 0x74, 0xdf, 0x9d, 0x2d, 0xc2, 0xec, 0x05, 0x8c, 0x43, 0x25,
+	0x2f, 0x50, 0xa0, 0xe6, 0x7a, 0x47, 0x21, 0xb3, 0x39, 0x90, 0x40, 0x0e, 0x87, 0x7e, 0x2d, 0xe5,
+	0x6a, 0x57, 0xc2, 0xd7, 0x70, 0x10, 0x12, 0x2e, 0x0d, 0x33, 0xcd, 0x4e, 0xf1, 0x97, 0x70, 0x14,
+	0xb8, 0xbf, 0x32, 0x53, 0xbc, 0xbf, 0x42, 0x6d, 0x04, 0x9a, 0x5d, 0x19, 0xef, 0xe0, 0x13, 0x8a,
+	0xba, 0x96, 0x42, 0xe3, 0x63, 0xc6, 0xe0, 0x29, 0xf4, 0x85, 0x2c, 0xd1, 0xee, 0xf8, 0x49, 0xe8,
+	0x59, 0xe8, 0xe7, 0xc3, 0x55, 0xb3, 0x7b, 0x57, 0xcd, 0xec, 0x05, 0x1c, 0x46, 0xed, 0x58, 0x98,
+	0x14, 0xfa, 0x4b, 0xbf, 0x74, 0xca, 0xfb, 0x34, 0xc2, 0xec, 0x3b, 0x38, 0x8a, 0xe4, 0x76, 0x61,
+	0x4e, 0x61, 0x3f, 0xb4, 0x3c, 0x5f, 0x48, 0xb9, 0x0a, 0x59, 0x23, 0x76, 0x47, 0xc9, 0xfe, 0xec,
+	0xc2, 0x38, 0xa6, 0x7e, 0xb4, 0x44, 0x76, 0x18, 0xb4, 0xb1, 0x4d, 0xf4, 0xe7, 0xf7, 0x80, 0x7c,
+	0x01, 0x70, 0xcd, 0xd6, 0xbc, 0x64, 0x46, 0x2a, 0xed, 0x2e, 0x91, 0xd0, 0x56, 0x84, 0xcc, 0xe1,
+	0x48, 0xe1, 0x87, 0x86, 0x2b, 0x2c, 0xf3, 0x16, 0xd1, 0x0e, 0xf7, 0x01, 0x25, 0x71, 0xeb, 0x97,
+	0xbb, 0x84, 0x67, 0x30, 0xae, 0xb8, 0x68, 0x73, 0x13, 0xc7, 0x3d, 0xa8, 0xb8, 0x78, 0x40, 0x63,
+	0x37, 0x6d, 0x5a, 0x2f, 0xd0, 0xd8, 0x4d, 0x8b, 0x96, 0x42, 0x5f, 0x1b, 0x26, 0xca, 0xc5, 0xc6,
+	0x0d, 0x7e, 0x42, 0x23, 0xb4, 0xd7, 0xb1, 0x1d, 0xd0, 0x6e, 0xe2, 0x13, 0xea, 0x01, 0xf9, 0x16,
+	0x06, 0x25, 0xb2, 0x72, 0xcd, 0x05, 0xba, 0xa1, 0x1f, 0x9d, 0x4f, 0x66, 0xde, 0x10, 0x66, 0xd1,
+	0x10, 0x66, 0x57, 0xd1, 0x10, 0xe8, 0x2d, 0xd7, 0x96, 0x01, 0x6f, 0x0c, 0x0a, 0xcd, 0xa5, 0xd0,
+	0xee, 0x65, 0x24, 0xb4, 0x15, 0xb1, 0x0f, 0xd1, 0xfa, 0x88, 0x6c, 0x4c, 0x2e, 0x1b, 0x53, 0xc8,
+	0x0a, 0xd3, 0x91, 0x7f, 0x88, 0x21, 0xfc, 0xb3, 0x8f, 0x66, 0x7f, 0x75, 0x20, 0xf9, 0x51, 0x29,
+	0xe9, 0x9e, 0x59, 0x21, 0x4b, 0x74, 0x6d, 0x48, 0xa8, 0x5b, 0xdb, 0xeb, 0x54, 0xa8, 0x35, 0x5b,
+	0xc6, 0x2e, 0x44, 0x78, 0xaf, 0x71, 0xdd, 0x2d, 0x8d, 0xdb, 0x6b, 0x37, 0xee, 0x18, 0x92, 0x35,
+	0xaf, 0xb8, 0x09, 0x4e, 0xe2, 0x81, 0x8d, 0xd6, 0x4c, 0xb1, 0xca, 0x55, 0x73, 0x48, 0x3d, 0x20,
+	0x9f, 0x41, 0x6f, 0x8d, 0xac, 0x44, 0x15, 0xdc, 0x23, 0xa0, 0xf3, 0xbf, 0xbb, 0x30, 0xfa, 0x21,
+	0x38, 0xe8, 0xab, 0xb7, 0x6f, 0xc8, 0x25, 0x0c, 0x6e, 0xdf, 0x42, 0x36, 0xfb, 0x8f, 0xb7, 0xce,
+	0x1e, 0xd8, 0xe6, 0xe4, 0xcb, 0xff, 0xe5, 0x3c, 0x78, 0x54, 0x97, 0x00, 0x17, 0x78, 0x6b, 0x10,
+	0xa7, 0xdb, 0x65, 0x03, 0x65, 0x92, 0xed, 0x50, 0x8d, 0x32, 0x39, 0x8c, 0x2f, 0xf0, 0x9e, 0x91,
+	0x3c, 0xdb, 0x2e, 0xdc, 0xa2, 0x4d, 0x9e, 0xef, 0x10, 0x6f, 0xcb, 0xbd, 0x85, 0xe1, 0x05, 0x46,
+	0xe3, 0x99, 0x6e, 0xd7, 0xf6, 0x8c, 0xc9, 0xe9, 0x0e, 0xd9, 0x20, 0xf2, 0x1b, 0xec, 0xdf, 0xf3,
+	0xa6, 0xe7, 0xdb, 0x45, 0xdb, 0xbc, 0x47, 0x48, 0xbf, 0xec, 0xbc, 0x3e, 0x7d, 0x77, 0x12, 0x59,
+	0xf3, 0x6a, 0x7e, 0x7d, 0x3e, 0x8f, 0x7f, 0x97, 0xdf, 0x17, 0x52, 0xa1, 0x5d, 0x2c, 0x7a, 0xee,
+	0x01, 0x7c, 0xf3, 0xef, 0x00, 0x93, 0x9b, 0x57, 0x4a, 0x4a, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// DirectorAPIClient is the client API for DirectorAPI service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type DirectorAPIClient interface {
+	Register(ctx context.Context, in *RequestRegister, opts ...grpc.CallOption) (*ResponseRegister, error)
+	GetGenesis(ctx context.Context, in *RequestGenesis, opts ...grpc.CallOption) (*ResponseGenesis, error)
+	GetAddressBook(ctx context.Context, in *RequestAddressBook, opts ...grpc.CallOption) (*ResponseAddressBook, error)
+	GetStatus(ctx context.Context, in *RequestStatus, opts ...grpc.CallOption) (*ResponseStatus, error)
+	// WatchTestnet sends the status of a testnet, then every change of it until the call is cancelled
+	WatchTestnet(ctx context.Context, in *RequestWatchTestnet, opts ...grpc.CallOption) (DirectorAPI_WatchTestnetClient, error)
+}
+
+type directorAPIClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewDirectorAPIClient(cc *grpc.ClientConn) DirectorAPIClient {
+	return &directorAPIClient{cc}
+}
+
+func (c *directorAPIClient) Register(ctx context.Context, in *RequestRegister, opts ...grpc.CallOption) (*ResponseRegister, error) {
+	out := new(ResponseRegister)
+	err := c.cc.Invoke(ctx, "/director.rpc.grpc.DirectorAPI/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *directorAPIClient) GetGenesis(ctx context.Context, in *RequestGenesis, opts ...grpc.CallOption) (*ResponseGenesis, error) {
+	out := new(ResponseGenesis)
+	err := c.cc.Invoke(ctx, "/director.rpc.grpc.DirectorAPI/GetGenesis", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *directorAPIClient) GetAddressBook(ctx context.Context, in *RequestAddressBook, opts ...grpc.CallOption) (*ResponseAddressBook, error) {
+	out := new(ResponseAddressBook)
+	err := c.cc.Invoke(ctx, "/director.rpc.grpc.DirectorAPI/GetAddressBook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *directorAPIClient) GetStatus(ctx context.Context, in *RequestStatus, opts ...grpc.CallOption) (*ResponseStatus, error) {
+	out := new(ResponseStatus)
+	err := c.cc.Invoke(ctx, "/director.rpc.grpc.DirectorAPI/GetStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *directorAPIClient) WatchTestnet(ctx context.Context, in *RequestWatchTestnet, opts ...grpc.CallOption) (DirectorAPI_WatchTestnetClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_DirectorAPI_serviceDesc.Streams[0], "/director.rpc.grpc.DirectorAPI/WatchTestnet", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &directorAPIWatchTestnetClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type DirectorAPI_WatchTestnetClient interface {
+	Recv() (*ResponseStatus, error)
+	grpc.ClientStream
+}
+
+type directorAPIWatchTestnetClient struct {
+	grpc.ClientStream
+}
+
+func (x *directorAPIWatchTestnetClient) Recv() (*ResponseStatus, error) {
+	m := new(ResponseStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DirectorAPIServer is the server API for DirectorAPI service.
+type DirectorAPIServer interface {
+	Register(context.Context, *RequestRegister) (*ResponseRegister, error)
+	GetGenesis(context.Context, *RequestGenesis) (*ResponseGenesis, error)
+	GetAddressBook(context.Context, *RequestAddressBook) (*ResponseAddressBook, error)
+	GetStatus(context.Context, *RequestStatus) (*ResponseStatus, error)
+	// WatchTestnet sends the status of a testnet, then every change of it until the call is cancelled
+	WatchTestnet(*RequestWatchTestnet, DirectorAPI_WatchTestnetServer) error
+}
+
+// UnimplementedDirectorAPIServer can be embedded to have forward compatible implementations.
+type UnimplementedDirectorAPIServer struct {
+}
+
+func (*UnimplementedDirectorAPIServer) Register(ctx context.Context, req *RequestRegister) (*ResponseRegister, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (*UnimplementedDirectorAPIServer) GetGenesis(ctx context.Context, req *RequestGenesis) (*ResponseGenesis, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGenesis not implemented")
+}
+func (*UnimplementedDirectorAPIServer) GetAddressBook(ctx context.Context, req *RequestAddressBook) (*ResponseAddressBook, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAddressBook not implemented")
+}
+func (*UnimplementedDirectorAPIServer) GetStatus(ctx context.Context, req *RequestStatus) (*ResponseStatus, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (*UnimplementedDirectorAPIServer) WatchTestnet(req *RequestWatchTestnet, srv DirectorAPI_WatchTestnetServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTestnet not implemented")
+}
+
+func RegisterDirectorAPIServer(s *grpc.Server, srv DirectorAPIServer) {
+	s.RegisterService(&_DirectorAPI_serviceDesc, srv)
+}
+
+func _DirectorAPI_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestRegister)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DirectorAPIServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/director.rpc.grpc.DirectorAPI/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DirectorAPIServer).Register(ctx, req.(*RequestRegister))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DirectorAPI_GetGenesis_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestGenesis)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DirectorAPIServer).GetGenesis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/director.rpc.grpc.DirectorAPI/GetGenesis",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DirectorAPIServer).GetGenesis(ctx, req.(*RequestGenesis))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DirectorAPI_GetAddressBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestAddressBook)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DirectorAPIServer).GetAddressBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/director.rpc.grpc.DirectorAPI/GetAddressBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DirectorAPIServer).GetAddressBook(ctx, req.(*RequestAddressBook))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DirectorAPI_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestStatus)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DirectorAPIServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/director.rpc.grpc.DirectorAPI/GetStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DirectorAPIServer).GetStatus(ctx, req.(*RequestStatus))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DirectorAPI_WatchTestnet_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RequestWatchTestnet)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DirectorAPIServer).WatchTestnet(m, &directorAPIWatchTestnetServer{stream})
+}
+
+type DirectorAPI_WatchTestnetServer interface {
+	Send(*ResponseStatus) error
+	grpc.ServerStream
+}
+
+type directorAPIWatchTestnetServer struct {
+	grpc.ServerStream
+}
+
+func (x *directorAPIWatchTestnetServer) Send(m *ResponseStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _DirectorAPI_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "director.rpc.grpc.DirectorAPI",
+	HandlerType: (*DirectorAPIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _DirectorAPI_Register_Handler,
+		},
+		{
+			MethodName: "GetGenesis",
+			Handler:    _DirectorAPI_GetGenesis_Handler,
+		},
+		{
+			MethodName: "GetAddressBook",
+			Handler:    _DirectorAPI_GetAddressBook_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _DirectorAPI_GetStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTestnet",
+			Handler:       _DirectorAPI_WatchTestnet_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rpc/grpc/types.proto",
+}
@@ -0,0 +1,231 @@
+// Package jsonrpc serves the RPC routes over JSON-RPC, URI calls and websockets.
+//
+// It is a fork of rpc/lib/server of Tendermint v0.33.x (rpc_func.go, http_json_handler.go, http_uri_handler.go
+// and ws_handler.go), which reports every error of a route as an internal error. The changes are:
+//   - the typed errors of the routes (see types.Error) are written with their code and data,
+//     and the responses are built as RPCResponse instead of rpctypes.RPCResponse
+//   - the errors of the server itself, like parse errors and unknown methods, are types.Error as well
+//   - a CallFilter can check every call of a websocket connection, for example against rate limits
+//   - the HTTP server, the CORS handling and the event subscriptions are not forked: the node uses
+//     rpc/lib/server for the listener and the websocket routes don't subscribe to events
+//
+// Fixes of the upstream handlers should be ported here when the Tendermint dependency is updated.
+package jsonrpc
+
+import (
+	"bytes"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RPCFunc is a route. Its function takes a *rpctypes.Context and the arguments, and returns a result and an error.
+type RPCFunc struct {
+	f        reflect.Value
+	args     []reflect.Type
+	argNames []string
+}
+
+// NewRPCFunc creates a route. args are the comma separated names of the arguments after the context.
+func NewRPCFunc(f interface{}, args string) *RPCFunc {
+	var argNames []string
+	if args != "" {
+		argNames = strings.Split(args, ",")
+	}
+	t := reflect.TypeOf(f)
+	argTypes := make([]reflect.Type, t.NumIn())
+	for i := range argTypes {
+		argTypes[i] = t.In(i)
+	}
+	return &RPCFunc{
+		f:        reflect.ValueOf(f),
+		args:     argTypes,
+		argNames: argNames,
+	}
+}
+
+// call calls the function of the route and returns its result and its error as is
+func (f *RPCFunc) call(ctx *rpctypes.Context, args []reflect.Value) (interface{}, error) {
+	returns := f.f.Call(append([]reflect.Value{reflect.ValueOf(ctx)}, args...))
+	if err, ok := returns[1].Interface().(error); ok && err != nil {
+		return nil, err
+	}
+	// the result may be a registered interface, amino needs a pointer to it to encode its type
+	result := reflect.New(returns[0].Type())
+	result.Elem().Set(returns[0])
+	return result.Interface(), nil
+}
+
+// RegisterRPCFuncs serves every route at /<name> for URI calls, and JSON-RPC calls at /
+func RegisterRPCFuncs(mux *http.ServeMux, funcMap map[string]*RPCFunc, cdc *amino.Codec, logger log.Logger) {
+	for name, rpcFunc := range funcMap {
+		mux.HandleFunc("/"+name, makeURIHandler(rpcFunc, cdc, logger))
+	}
+	mux.HandleFunc("/", makeJSONRPCHandler(funcMap, cdc, logger))
+}
+
+// makeJSONRPCHandler serves single and batch JSON-RPC calls
+func makeJSONRPCHandler(funcMap map[string]*RPCFunc, cdc *amino.Codec, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// "/" matches every path that is not registered
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			WriteError(w, http.StatusOK, nil, types.NewError(types.CodeInvalidRequest, "error reading request body: %v", err), logger)
+			return
+		}
+		// an empty request, for example from a browser, lists the routes
+		if len(body) == 0 {
+			writeListOfEndpoints(w, r, funcMap)
+			return
+		}
+
+		var requests []rpctypes.RPCRequest
+		if err := json.Unmarshal(body, &requests); err != nil {
+			var request rpctypes.RPCRequest
+			if err := json.Unmarshal(body, &request); err != nil {
+				WriteError(w, http.StatusOK, nil, types.NewError(types.CodeParseError, "error unmarshalling request: %v", err), logger)
+				return
+			}
+			requests = []rpctypes.RPCRequest{request}
+		}
+
+		var responses []RPCResponse
+		for i := range requests {
+			request := &requests[i]
+			// notifications, requests without ID, get no response
+			if request.ID == nil {
+				logger.Debug("Skipping JSON-RPC notification", "method", request.Method)
+				continue
+			}
+			ctx := &rpctypes.Context{JSONReq: request, HTTPReq: r}
+			responses = append(responses, callJSONRPC(ctx, funcMap, cdc, request))
+			logger.Info("HTTPJSONRPC", "method", request.Method)
+		}
+		if len(responses) > 0 {
+			writeResponses(w, http.StatusOK, responses, logger)
+		}
+	}
+}
+
+// callJSONRPC calls the route of a JSON-RPC request over HTTP or websocket
+func callJSONRPC(ctx *rpctypes.Context, funcMap map[string]*RPCFunc, cdc *amino.Codec, request *rpctypes.RPCRequest) RPCResponse {
+	id := requestID(request)
+	rpcFunc, ok := funcMap[request.Method]
+	if !ok {
+		return NewErrorResponse(id, types.NewError(types.CodeMethodNotFound, "method %s not found", request.Method))
+	}
+	var args []reflect.Value
+	if len(request.Params) > 0 {
+		var err error
+		if args, err = jsonParamsToArgs(rpcFunc, cdc, request.Params); err != nil {
+			return NewErrorResponse(id, invalidParams(err))
+		}
+	} else {
+		args = zeroArgs(rpcFunc)
+	}
+	result, err := rpcFunc.call(ctx, args)
+	if err != nil {
+		return NewErrorResponse(id, err)
+	}
+	return newSuccessResponse(cdc, id, result)
+}
+
+// invalidParams is the error of params that don't match the arguments of a route
+func invalidParams(err error) *types.Error {
+	return types.NewError(types.CodeInvalidParam, "error converting params to arguments: %v", err)
+}
+
+// jsonParamsToArgs decodes named or positional params
+func jsonParamsToArgs(rpcFunc *RPCFunc, cdc *amino.Codec, raw json.RawMessage) ([]reflect.Value, error) {
+	var named map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &named); err == nil {
+		values := zeroArgs(rpcFunc)
+		for i, name := range rpcFunc.argNames {
+			if p := named[name]; len(p) > 0 {
+				if values[i], err = decodeArg(cdc, rpcFunc.args[i+1], p); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return values, nil
+	}
+
+	var positional []json.RawMessage
+	if err := json.Unmarshal(raw, &positional); err != nil {
+		return nil, errors.Errorf("unknown type for JSON params: %v. Expected map or array", err)
+	}
+	if len(positional) != len(rpcFunc.argNames) {
+		return nil, errors.Errorf("expected %v parameters (%v), got %v", len(rpcFunc.argNames), rpcFunc.argNames, len(positional))
+	}
+	values := make([]reflect.Value, len(positional))
+	for i, p := range positional {
+		var err error
+		if values[i], err = decodeArg(cdc, rpcFunc.args[i+1], p); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// zeroArgs returns the default value of every argument of a route
+func zeroArgs(rpcFunc *RPCFunc) []reflect.Value {
+	values := make([]reflect.Value, len(rpcFunc.argNames))
+	for i := range values {
+		values[i] = reflect.Zero(rpcFunc.args[i+1])
+	}
+	return values
+}
+
+// decodeArg decodes a JSON argument with amino
+func decodeArg(cdc *amino.Codec, argType reflect.Type, raw []byte) (reflect.Value, error) {
+	value := reflect.New(argType)
+	if err := cdc.UnmarshalJSON(raw, value.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return value.Elem(), nil
+}
+
+// writeListOfEndpoints writes the routes as an html page
+func writeListOfEndpoints(w http.ResponseWriter, r *http.Request, funcMap map[string]*RPCFunc) {
+	var noArgNames, argNames []string
+	for name, rpcFunc := range funcMap {
+		if len(rpcFunc.argNames) == 0 {
+			noArgNames = append(noArgNames, name)
+		} else {
+			argNames = append(argNames, name)
+		}
+	}
+	sort.Strings(noArgNames)
+	sort.Strings(argNames)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("<html><body>")
+	buf.WriteString("<br>Available endpoints:<br>")
+	for _, name := range noArgNames {
+		link := fmt.Sprintf("//%s/%s", r.Host, name)
+		buf.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a></br>", link, link))
+	}
+	buf.WriteString("<br>Endpoints that require arguments:<br>")
+	for _, name := range argNames {
+		link := fmt.Sprintf("//%s/%s?%s=_", r.Host, name, strings.Join(funcMap[name].argNames, "=_&"))
+		buf.WriteString(fmt.Sprintf("<a href=\"%s\">%s</a></br>", link, link))
+	}
+	buf.WriteString("</body></html>")
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes()) // nolint: errcheck
+}
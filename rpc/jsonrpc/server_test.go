@@ -0,0 +1,157 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"director/m/v2/types"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+)
+
+type resultEcho struct {
+	Value string `json:"value"`
+}
+
+// newTestServer serves a route that echoes its argument, fails with a typed error for "typed" and an untyped error for "untyped"
+func newTestServer() *httptest.Server {
+	routes := map[string]*RPCFunc{
+		"echo": NewRPCFunc(func(ctx *rpctypes.Context, value string) (*resultEcho, error) {
+			switch value {
+			case "typed":
+				return nil, &types.Error{Code: types.CodeUnregisteredTestnet, Message: "unregistered testnet", Data: types.ErrorData{ChainID: "default"}}
+			case "untyped":
+				return nil, errors.New("disk full")
+			}
+			return &resultEcho{Value: value}, nil
+		}, "value"),
+	}
+	cdc := amino.NewCodec()
+	mux := http.NewServeMux()
+	wm := NewWebsocketManager(routes, cdc, 0)
+	mux.HandleFunc("/websocket", wm.WebsocketHandler)
+	RegisterRPCFuncs(mux, routes, cdc, log.NewNopLogger())
+	return httptest.NewServer(mux)
+}
+
+func readResponse(t *testing.T, res *http.Response, response interface{}) {
+	defer res.Body.Close() // nolint: errcheck
+	content, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(content, response), string(content))
+}
+
+func postJSONRPC(t *testing.T, url string, body string, response interface{}) {
+	res, err := http.Post(url, "application/json", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	readResponse(t, res, response)
+}
+
+// assertTypedError checks the error of a response to the "typed" call
+func assertTypedError(t *testing.T, response RPCResponse) {
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeUnregisteredTestnet, response.Error.Code)
+	assert.Equal(t, "unregistered testnet", response.Error.Message)
+	assert.Equal(t, "default", response.Error.Data.ChainID)
+}
+
+func TestJSONRPCErrors(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	var response RPCResponse
+	postJSONRPC(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": {"value": "hello"}}`, &response)
+	require.Nil(t, response.Error)
+	assert.Equal(t, float64(1), response.ID)
+	assert.JSONEq(t, `{"value": "hello"}`, string(response.Result))
+
+	response = RPCResponse{}
+	postJSONRPC(t, server.URL, `{"jsonrpc": "2.0", "id": "a", "method": "echo", "params": ["typed"]}`, &response)
+	assert.Equal(t, "a", response.ID)
+	assertTypedError(t, response)
+
+	response = RPCResponse{}
+	postJSONRPC(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": {"value": "untyped"}}`, &response)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeInternalError, response.Error.Code)
+	assert.Equal(t, "disk full", response.Error.Message)
+
+	response = RPCResponse{}
+	postJSONRPC(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": {"value": 1}}`, &response)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeInvalidParam, response.Error.Code)
+
+	response = RPCResponse{}
+	postJSONRPC(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "unknown"}`, &response)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeMethodNotFound, response.Error.Code)
+
+	response = RPCResponse{}
+	postJSONRPC(t, server.URL, `{"jsonrpc": `, &response)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeParseError, response.Error.Code)
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	var responses []RPCResponse
+	postJSONRPC(t, server.URL, `[
+		{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": {"value": "hello"}},
+		{"jsonrpc": "2.0", "method": "echo", "params": {"value": "notification"}},
+		{"jsonrpc": "2.0", "id": 2, "method": "echo", "params": {"value": "typed"}}
+	]`, &responses)
+	require.Len(t, responses, 2)
+	assert.Nil(t, responses[0].Error)
+	assert.Equal(t, float64(2), responses[1].ID)
+	assertTypedError(t, responses[1])
+}
+
+func TestURIErrors(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	res, err := http.Get(server.URL + `/echo?value="hello"`)
+	require.NoError(t, err)
+	var response RPCResponse
+	readResponse(t, res, &response)
+	require.Nil(t, response.Error)
+	assert.JSONEq(t, `{"value": "hello"}`, string(response.Result))
+
+	res, err = http.Get(server.URL + `/echo?value="typed"`)
+	require.NoError(t, err)
+	response = RPCResponse{}
+	readResponse(t, res, &response)
+	assertTypedError(t, response)
+}
+
+func TestWebsocketErrors(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	conn, _, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http://", "ws://", 1)+"/websocket", nil)
+	require.NoError(t, err)
+	defer conn.Close() // nolint: errcheck
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc": "2.0", "id": 1, "method": "echo", "params": {"value": "hello"}}`)))
+	var response RPCResponse
+	require.NoError(t, conn.ReadJSON(&response))
+	require.Nil(t, response.Error)
+	assert.JSONEq(t, `{"value": "hello"}`, string(response.Result))
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc": "2.0", "id": 2, "method": "echo", "params": {"value": "typed"}}`)))
+	response = RPCResponse{}
+	require.NoError(t, conn.ReadJSON(&response))
+	assert.Equal(t, float64(2), response.ID)
+	assertTypedError(t, response)
+}
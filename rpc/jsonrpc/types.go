@@ -0,0 +1,70 @@
+package jsonrpc
+
+import (
+	"director/m/v2/types"
+	"encoding/json"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"net/http"
+)
+
+// uriRequestID is the ID of the responses to URI calls, which have no request ID
+var uriRequestID = rpctypes.JSONRPCIntID(-1)
+
+// RPCResponse is a JSON-RPC 2.0 response. Its error is the typed error of the call, with a data object.
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *types.Error    `json:"error,omitempty"`
+}
+
+// NewErrorResponse returns the response of a failed call with the request ID id.
+// Untyped errors are internal errors.
+func NewErrorResponse(id interface{}, err error) RPCResponse {
+	return RPCResponse{JSONRPC: "2.0", ID: id, Error: types.ToError(err)}
+}
+
+// newSuccessResponse encodes the result of a call with amino, as Tendermint does
+func newSuccessResponse(cdc *amino.Codec, id interface{}, result interface{}) RPCResponse {
+	content, err := cdc.MarshalJSON(result)
+	if err != nil {
+		return NewErrorResponse(id, err)
+	}
+	return RPCResponse{JSONRPC: "2.0", ID: id, Result: content}
+}
+
+// requestID returns the ID of a request, or nil if it has none
+func requestID(request *rpctypes.RPCRequest) interface{} {
+	if request == nil || request.ID == nil {
+		return nil
+	}
+	return request.ID
+}
+
+// WriteError writes the response of a failed call with the request ID id and the HTTP status status
+func WriteError(w http.ResponseWriter, status int, id interface{}, err error, logger log.Logger) {
+	writeResponses(w, status, []RPCResponse{NewErrorResponse(id, err)}, logger)
+}
+
+// writeResponses writes a single response as an object and the responses of a batch as an array
+func writeResponses(w http.ResponseWriter, status int, responses []RPCResponse, logger log.Logger) {
+	var content []byte
+	var err error
+	if len(responses) == 1 {
+		content, err = json.MarshalIndent(responses[0], "", "  ")
+	} else {
+		content, err = json.MarshalIndent(responses, "", "  ")
+	}
+	if err != nil {
+		logger.Error("Error encoding RPC response", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(content); err != nil {
+		logger.Error("Error writing RPC response", "err", err)
+	}
+}
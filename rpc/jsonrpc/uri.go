@@ -0,0 +1,104 @@
+package jsonrpc
+
+import (
+	"encoding/hex"
+	"github.com/pkg/errors"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	rpcserver "github.com/tendermint/tendermint/rpc/lib/server"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// makeURIHandler serves the calls of a route with the arguments in the query, for example /genesis?chain_id="default"
+func makeURIHandler(rpcFunc *RPCFunc, cdc *amino.Codec, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		args, err := httpParamsToArgs(rpcFunc, cdc, r)
+		if err != nil {
+			WriteError(w, http.StatusOK, uriRequestID, invalidParams(err), logger)
+			return
+		}
+		logger.Info("HTTPRestRPC", "method", r.URL.Path)
+		result, err := rpcFunc.call(&rpctypes.Context{HTTPReq: r}, args)
+		if err != nil {
+			WriteError(w, http.StatusOK, uriRequestID, err, logger)
+			return
+		}
+		writeResponses(w, http.StatusOK, []RPCResponse{newSuccessResponse(cdc, uriRequestID, result)}, logger)
+	}
+}
+
+// httpParamsToArgs converts the query parameters of a URI call to the arguments of a route.
+// Parameters are JSON, integers, or hex strings starting with 0x for strings and byte slices.
+func httpParamsToArgs(rpcFunc *RPCFunc, cdc *amino.Codec, r *http.Request) ([]reflect.Value, error) {
+	values := zeroArgs(rpcFunc)
+	for i, name := range rpcFunc.argNames {
+		arg := rpcserver.GetParam(r, name)
+		if arg == "" {
+			continue
+		}
+		argType := rpcFunc.args[i+1]
+		value, ok, err := nonJSONStringToArg(cdc, argType, arg)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if value, err = decodeArg(cdc, argType, []byte(arg)); err != nil {
+				return nil, err
+			}
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// nonJSONStringToArg converts integers, hex strings and quoted strings for byte slices. It returns false for JSON arguments.
+func nonJSONStringToArg(cdc *amino.Codec, argType reflect.Type, arg string) (reflect.Value, bool, error) {
+	if argType.Kind() == reflect.Ptr {
+		value, ok, err := nonJSONStringToArg(cdc, argType.Elem(), arg)
+		if err != nil || !ok {
+			return reflect.Value{}, false, err
+		}
+		ptr := reflect.New(argType.Elem())
+		ptr.Elem().Set(value)
+		return ptr, true, nil
+	}
+
+	var expectingString, expectingByteSlice, expectingInt bool
+	switch argType.Kind() {
+	case reflect.Int, reflect.Uint, reflect.Int8, reflect.Uint8, reflect.Int16, reflect.Uint16,
+		reflect.Int32, reflect.Uint32, reflect.Int64, reflect.Uint64:
+		expectingInt = true
+	case reflect.String:
+		expectingString = true
+	case reflect.Slice:
+		expectingByteSlice = argType.Elem().Kind() == reflect.Uint8
+	}
+
+	switch {
+	case expectingInt && rpcserver.ReInt.MatchString(arg):
+		value, err := decodeArg(cdc, argType, []byte(`"`+arg+`"`))
+		return value, err == nil, err
+	case strings.HasPrefix(strings.ToLower(arg), "0x"):
+		if !expectingString && !expectingByteSlice {
+			return reflect.Value{}, false, errors.Errorf("got a hex string arg, but expected '%s'", argType.Kind())
+		}
+		content, err := hex.DecodeString(arg[2:])
+		if err != nil {
+			return reflect.Value{}, false, err
+		}
+		if expectingString {
+			return reflect.ValueOf(string(content)), true, nil
+		}
+		return reflect.ValueOf(content), true, nil
+	case expectingByteSlice && strings.HasPrefix(arg, `"`) && strings.HasSuffix(arg, `"`):
+		var s string
+		if err := cdc.UnmarshalJSON([]byte(arg), &s); err != nil {
+			return reflect.Value{}, false, err
+		}
+		return reflect.ValueOf([]byte(s)), true, nil
+	}
+	return reflect.Value{}, false, nil
+}
@@ -0,0 +1,295 @@
+package jsonrpc
+
+import (
+	"context"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/websocket"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/libs/service"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	wsWriteChanCapacity = 1000
+	wsWriteWait         = 10 * time.Second
+	wsReadWait          = 30 * time.Second
+	wsPingPeriod        = (wsReadWait * 9) / 10
+)
+
+// CallFilter checks a call received over a websocket connection before its route is called.
+// remoteAddr is the address of the client. A returned error is sent to the client instead of calling the route.
+type CallFilter func(remoteAddr string, request *rpctypes.RPCRequest) error
+
+// WebsocketManager serves JSON-RPC calls over websocket connections
+type WebsocketManager struct {
+	websocket.Upgrader
+
+	funcMap    map[string]*RPCFunc
+	cdc        *amino.Codec
+	readLimit  int64
+	callFilter CallFilter
+	logger     log.Logger
+}
+
+// NewWebsocketManager returns a websocket handler for the routes of funcMap.
+// Messages over readLimit bytes close the connection, 0 means no limit.
+func NewWebsocketManager(funcMap map[string]*RPCFunc, cdc *amino.Codec, readLimit int64) *WebsocketManager {
+	return &WebsocketManager{
+		Upgrader: websocket.Upgrader{
+			// clients of any origin may call the API, as over HTTP
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		funcMap:   funcMap,
+		cdc:       cdc,
+		readLimit: readLimit,
+		logger:    log.NewNopLogger(),
+	}
+}
+
+// SetLogger sets the logger
+func (wm *WebsocketManager) SetLogger(logger log.Logger) {
+	wm.logger = logger
+}
+
+// SetCallFilter sets a filter checking every call of the connections, for example against rate limits
+func (wm *WebsocketManager) SetCallFilter(filter CallFilter) {
+	wm.callFilter = filter
+}
+
+// WebsocketHandler upgrades the request to a websocket connection and serves it until it is closed
+func (wm *WebsocketManager) WebsocketHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := wm.Upgrade(w, r, nil)
+	if err != nil {
+		wm.logger.Error("Failed to upgrade connection", "err", err)
+		return
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			wm.logger.Error("Failed to close connection", "err", err)
+		}
+	}()
+
+	wsc := newWSConnection(conn, wm.funcMap, wm.cdc, wm.readLimit, wm.callFilter)
+	wsc.SetLogger(wm.logger.With("remote", wsc.remoteAddr))
+	wm.logger.Info("New websocket connection", "remote", wsc.remoteAddr)
+	// blocks until the connection is closed
+	if err := wsc.Start(); err != nil {
+		wm.logger.Error("Failed to start connection", "err", err)
+		return
+	}
+	wsc.Stop() // nolint: errcheck
+}
+
+// wsConnection serves the calls of a websocket connection. It implements rpctypes.WSRPCConnection.
+type wsConnection struct {
+	service.BaseService
+
+	remoteAddr string
+	baseConn   *websocket.Conn
+	// never closed, so writes after the connection stopped fail instead of panicking
+	writeChan chan RPCResponse
+	// closed when the read routine fails, to stop the write routine
+	readRoutineQuit chan struct{}
+
+	funcMap    map[string]*RPCFunc
+	cdc        *amino.Codec
+	callFilter CallFilter
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newWSConnection(conn *websocket.Conn, funcMap map[string]*RPCFunc, cdc *amino.Codec, readLimit int64, callFilter CallFilter) *wsConnection {
+	wsc := &wsConnection{
+		remoteAddr:      conn.RemoteAddr().String(),
+		baseConn:        conn,
+		readRoutineQuit: make(chan struct{}),
+		funcMap:         funcMap,
+		cdc:             cdc,
+		callFilter:      callFilter,
+	}
+	wsc.ctx, wsc.cancel = context.WithCancel(context.Background())
+	wsc.baseConn.SetReadLimit(readLimit)
+	wsc.BaseService = *service.NewBaseService(nil, "wsConnection", wsc)
+	return wsc
+}
+
+// OnStart implements service.Service. It serves the connection until it fails or is closed.
+func (wsc *wsConnection) OnStart() error {
+	wsc.writeChan = make(chan RPCResponse, wsWriteChanCapacity)
+	go wsc.readRoutine()
+	wsc.writeRoutine()
+	return nil
+}
+
+// OnStop implements service.Service
+func (wsc *wsConnection) OnStop() {
+	wsc.cancel()
+}
+
+// GetRemoteAddr implements rpctypes.WSRPCConnection
+func (wsc *wsConnection) GetRemoteAddr() string {
+	return wsc.remoteAddr
+}
+
+// WriteRPCResponse implements rpctypes.WSRPCConnection. It blocks until the response is queued.
+func (wsc *wsConnection) WriteRPCResponse(res rpctypes.RPCResponse) {
+	wsc.write(fromTendermintResponse(res))
+}
+
+// TryWriteRPCResponse implements rpctypes.WSRPCConnection. It returns false if the response can't be queued right away.
+func (wsc *wsConnection) TryWriteRPCResponse(res rpctypes.RPCResponse) bool {
+	select {
+	case <-wsc.Quit():
+		return false
+	case wsc.writeChan <- fromTendermintResponse(res):
+		return true
+	default:
+		return false
+	}
+}
+
+// Codec implements rpctypes.WSRPCConnection
+func (wsc *wsConnection) Codec() *amino.Codec {
+	return wsc.cdc
+}
+
+// Context implements rpctypes.WSRPCConnection. It is canceled when the connection closes.
+func (wsc *wsConnection) Context() context.Context {
+	return wsc.ctx
+}
+
+// write queues a response and blocks until it is accepted or the connection stopped
+func (wsc *wsConnection) write(res RPCResponse) {
+	select {
+	case <-wsc.Quit():
+	case wsc.writeChan <- res:
+	}
+}
+
+// fromTendermintResponse converts the responses written by Tendermint code. Their errors are not typed.
+func fromTendermintResponse(res rpctypes.RPCResponse) RPCResponse {
+	response := RPCResponse{JSONRPC: res.JSONRPC, ID: res.ID, Result: res.Result}
+	if res.Error != nil {
+		response.Error = &types.Error{Code: types.ErrorCode(res.Error.Code), Message: res.Error.Message}
+		if res.Error.Data != "" {
+			response.Error.Message += ": " + res.Error.Data
+		}
+	}
+	return response
+}
+
+// readRoutine reads the requests of the connection and calls their routes
+func (wsc *wsConnection) readRoutine() {
+	var request rpctypes.RPCRequest
+	defer func() {
+		if r := recover(); r != nil {
+			wsc.Logger.Error("Panic in WSJSONRPC handler", "err", r, "stack", string(debug.Stack()))
+			wsc.write(NewErrorResponse(requestID(&request), fmt.Errorf("%v", r)))
+			go wsc.readRoutine()
+		}
+	}()
+
+	wsc.baseConn.SetPongHandler(func(string) error {
+		return wsc.baseConn.SetReadDeadline(time.Now().Add(wsReadWait))
+	})
+
+	for {
+		select {
+		case <-wsc.Quit():
+			return
+		default:
+		}
+
+		// every message, including control messages, resets the deadline
+		if err := wsc.baseConn.SetReadDeadline(time.Now().Add(wsReadWait)); err != nil {
+			wsc.Logger.Error("Failed to set read deadline", "err", err)
+		}
+		_, in, err := wsc.baseConn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				wsc.Logger.Info("Client closed the connection")
+			} else {
+				wsc.Logger.Error("Failed to read request", "err", err)
+			}
+			wsc.Stop() // nolint: errcheck
+			close(wsc.readRoutineQuit)
+			return
+		}
+
+		request = rpctypes.RPCRequest{}
+		if err := json.Unmarshal(in, &request); err != nil {
+			wsc.write(NewErrorResponse(nil, types.NewError(types.CodeParseError, "error unmarshalling request: %v", err)))
+			continue
+		}
+		// notifications, requests without ID, get no response
+		if request.ID == nil {
+			wsc.Logger.Debug("Skipping JSON-RPC notification", "method", request.Method)
+			continue
+		}
+		if wsc.callFilter != nil {
+			if err := wsc.callFilter(wsc.remoteAddr, &request); err != nil {
+				wsc.write(NewErrorResponse(requestID(&request), err))
+				continue
+			}
+		}
+		wsc.Logger.Info("WSJSONRPC", "method", request.Method)
+		wsc.write(callJSONRPC(&rpctypes.Context{JSONReq: &request, WSConn: wsc}, wsc.funcMap, wsc.cdc, &request))
+	}
+}
+
+// writeRoutine writes the queued responses and the pings of the connection
+func (wsc *wsConnection) writeRoutine() {
+	pingTicker := time.NewTicker(wsPingPeriod)
+	defer pingTicker.Stop()
+
+	// https://github.com/gorilla/websocket/issues/97
+	pongs := make(chan string, 1)
+	wsc.baseConn.SetPingHandler(func(m string) error {
+		select {
+		case pongs <- m:
+		default:
+		}
+		return nil
+	})
+
+	for {
+		select {
+		case <-wsc.Quit():
+			return
+		case <-wsc.readRoutineQuit:
+			return
+		case m := <-pongs:
+			if err := wsc.writeMessageWithDeadline(websocket.PongMessage, []byte(m)); err != nil {
+				wsc.Logger.Info("Failed to write pong (client may disconnect)", "err", err)
+			}
+		case <-pingTicker.C:
+			if err := wsc.writeMessageWithDeadline(websocket.PingMessage, []byte{}); err != nil {
+				wsc.Logger.Error("Failed to write ping", "err", err)
+				return
+			}
+		case res := <-wsc.writeChan:
+			content, err := json.MarshalIndent(res, "", "  ")
+			if err != nil {
+				wsc.Logger.Error("Failed to encode RPC response", "err", err)
+			} else if err := wsc.writeMessageWithDeadline(websocket.TextMessage, content); err != nil {
+				wsc.Logger.Error("Failed to write response", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// writeMessageWithDeadline writes a message. Every write sets the deadline, see https://github.com/tendermint/tendermint/issues/553
+func (wsc *wsConnection) writeMessageWithDeadline(msgType int, msg []byte) error {
+	if err := wsc.baseConn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return wsc.baseConn.WriteMessage(msgType, msg)
+}
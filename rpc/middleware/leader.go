@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"director/m/v2/rpc/core"
+	"github.com/tendermint/tendermint/libs/log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// LeaderGuard forwards the writes a follower receives over HTTP to the leader, or rejects them.
+// Websocket connections stay with the follower, which serves their reads. Their writes are rejected
+// by the write routes (see core.SetLeadership): a proxy can't send single messages of a connection to the leader.
+type LeaderGuard struct {
+	leadership core.Leadership
+	proxy      bool
+	logger     log.Logger
+}
+
+// NewLeaderGuard returns a guard that proxies writes to the leader if proxy is set, and rejects them otherwise.
+func NewLeaderGuard(leadership core.Leadership, proxy bool, logger log.Logger) *LeaderGuard {
+	return &LeaderGuard{
+		leadership: leadership,
+		proxy:      proxy,
+		logger:     logger,
+	}
+}
+
+// Handler wraps next and handles the writes while this director is a follower.
+func (g *LeaderGuard) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.leadership.IsLeader() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		requests, write := parseWrites(r)
+		if !write {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		leader, err := g.leadership.Leader()
+		if err != nil {
+			g.logger.Error("Can't find the leader", "err", err)
+		}
+		if g.proxy && leader != "" {
+			target, err := url.Parse(leader)
+			if err == nil {
+				g.logger.Debug("Forwarding write to the leader", "url", r.URL, "leader", leader)
+				httputil.NewSingleHostReverseProxy(target).ServeHTTP(w, r)
+				return
+			}
+			g.logger.Error("Invalid leader address", "leader", leader, "err", err)
+		}
+
+		writeError(w, r, http.StatusServiceUnavailable, requests, core.ErrNotLeader(leader), g.logger)
+	})
+}
+
+// parseWrites returns the calls of an HTTP request and true if one of them is a write. Websocket connections are not writes.
+func parseWrites(r *http.Request) ([]request, bool) {
+	if r.URL.Path == "/websocket" {
+		return nil, false
+	}
+	requests, err := parseRequests(r)
+	if err != nil {
+		// Let the RPC server report malformed requests
+		return nil, false
+	}
+	for _, req := range requests {
+		if core.WriteMethods[req.method] {
+			return requests, true
+		}
+	}
+	return requests, false
+}
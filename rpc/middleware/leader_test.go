@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"director/m/v2/config"
+	"director/m/v2/rpc/core"
+	"director/m/v2/rpc/jsonrpc"
+	"director/m/v2/state"
+	"director/m/v2/store"
+	"director/m/v2/types"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	rpcserver "github.com/tendermint/tendermint/rpc/lib/server"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// follower is the leadership of a director following leader
+type follower struct {
+	leader string
+}
+
+func (f *follower) IsLeader() bool {
+	return false
+}
+
+func (f *follower) Leader() (string, error) {
+	return f.leader, nil
+}
+
+// newFollowerServer serves the RPC routes of a follower of leader behind the leader guard
+func newFollowerServer(t *testing.T, leader string, proxy bool) *httptest.Server {
+	testnetStore := store.NewStore(dbm.NewMemDB(), map[string]config.TestnetsTOMLConfig{"default": {RequiredValidators: 4}})
+	logger := log.NewNopLogger()
+	core.SetLogger(logger)
+	core.SetStateMachine(state.NewMachine(testnetStore, logger, time.Second))
+	leadership := &follower{leader: leader}
+	core.SetLeadership(leadership)
+
+	cdc := amino.NewCodec()
+	mux := http.NewServeMux()
+	wm := jsonrpc.NewWebsocketManager(core.Routes, cdc, 0)
+	wm.SetLogger(logger)
+	mux.HandleFunc("/websocket", wm.WebsocketHandler)
+	jsonrpc.RegisterRPCFuncs(mux, core.Routes, cdc, logger)
+	return httptest.NewServer(NewLeaderGuard(leadership, proxy, logger).Handler(mux))
+}
+
+func postRPC(t *testing.T, url string, method string) (int, jsonrpc.RPCResponse) {
+	body, err := json.Marshal(rpctypes.NewRPCRequest(rpctypes.JSONRPCIntID(1), method, json.RawMessage(`{}`)))
+	require.NoError(t, err)
+	res, err := http.Post(url, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	content, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	var response jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(content, &response), string(content))
+	return res.StatusCode, response
+}
+
+// callWebsocket sends a JSON-RPC request over a websocket connection and reads the response
+func callWebsocket(t *testing.T, conn *websocket.Conn, method string, params string) jsonrpc.RPCResponse {
+	require.NoError(t, conn.WriteJSON(rpctypes.NewRPCRequest(rpctypes.JSONRPCIntID(1), method, json.RawMessage(params))))
+	var response jsonrpc.RPCResponse
+	require.NoError(t, conn.ReadJSON(&response))
+	return response
+}
+
+func TestLeaderGuardRejectsWrites(t *testing.T) {
+	server := newFollowerServer(t, "http://leader:27001", false)
+	defer server.Close()
+
+	status, response := postRPC(t, server.URL, "register")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeNotLeader, response.Error.Code)
+	assert.Equal(t, "http://leader:27001", response.Error.Data.Leader)
+	assert.Equal(t, float64(1), response.ID)
+
+	status, response = postRPC(t, server.URL, "testnets")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, response.Error)
+}
+
+func TestLeaderGuardProxiesWrites(t *testing.T) {
+	leader := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rpcserver.WriteRPCResponseHTTP(w, rpctypes.NewRPCSuccessResponse(amino.NewCodec(), rpctypes.JSONRPCIntID(1), "from the leader"))
+	}))
+	defer leader.Close()
+	server := newFollowerServer(t, leader.URL, true)
+	defer server.Close()
+
+	status, response := postRPC(t, server.URL, "register")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, response.Error)
+	assert.Equal(t, `"from the leader"`, string(response.Result))
+}
+
+// Websocket connections stay with the follower: reads are served, writes are rejected by the routes
+func TestFollowerWebsocket(t *testing.T) {
+	server := newFollowerServer(t, "http://leader:27001", true)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http://", "ws://", 1)+"/websocket", nil)
+	require.NoError(t, err)
+	defer conn.Close() // nolint: errcheck
+
+	response := callWebsocket(t, conn, "testnets", `{}`)
+	require.Nil(t, response.Error)
+	assert.Contains(t, string(response.Result), "default")
+
+	// Typed errors keep their code and data over the websocket
+	response = callWebsocket(t, conn, "register", `{"chain_id": "default"}`)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeNotLeader, response.Error.Code)
+	assert.Equal(t, "http://leader:27001", response.Error.Data.Leader)
+}
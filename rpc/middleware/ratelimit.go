@@ -0,0 +1,312 @@
+package middleware
+
+import (
+	"bytes"
+	cfg "director/m/v2/config"
+	"director/m/v2/rpc/core"
+	"director/m/v2/rpc/jsonrpc"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/tendermint/tendermint/libs/log"
+	rpcserver "github.com/tendermint/tendermint/rpc/lib/server"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cleanupInterval defines how often idle clients are dropped from memory
+const cleanupInterval = 5 * time.Minute
+
+// writeMethods lists the RPC methods that count against the per chain limit
+var writeMethods = map[string]bool{
+	"register":      true,
+	"register_json": true,
+}
+
+// RateLimiter throttles requests per remote IP and registrations per chain ID,
+// and temporarily bans remote IPs that keep hitting the limit.
+type RateLimiter struct {
+	config *cfg.RPCConfig
+	logger log.Logger
+
+	mtx         sync.Mutex
+	clients     map[string]*client
+	chains      map[string]*bucket
+	lastCleanup time.Time
+}
+
+// client tracks the state of a single remote IP
+type client struct {
+	bucket      bucket
+	strikes     int
+	lastStrike  time.Time
+	bannedUntil time.Time
+}
+
+// bucket is a token bucket refilled at a fixed rate
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a rate limiter using the limits of the [rpc] config section.
+func NewRateLimiter(config *cfg.RPCConfig, logger log.Logger) *RateLimiter {
+	return &RateLimiter{
+		config:      config,
+		logger:      logger,
+		clients:     map[string]*client{},
+		chains:      map[string]*bucket{},
+		lastCleanup: time.Now(),
+	}
+}
+
+// Handler wraps next and rejects requests that are over the limit.
+// Every call of a batch costs one token, malformed requests cost one token as well.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := remoteIP(r)
+		if r.Body != nil && l.config.MaxBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, l.config.MaxBodyBytes)
+		}
+		requests, err := parseRequests(r)
+
+		retryAfter, reason := l.allow(ip, requests, time.Now())
+		if reason != "" {
+			l.logger.Info("Throttled request", "ip", ip, "url", r.URL, "reason", reason)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			writeError(w, r, http.StatusTooManyRequests, requests, types.NewError(types.CodeTooManyRequests, "%s", reason), l.logger)
+			return
+		}
+
+		if _, ok := err.(bodyError); ok {
+			writeError(w, r, http.StatusBadRequest, nil, types.NewError(types.CodeInvalidRequest, "%v", err), l.logger)
+			return
+		}
+		// Let the RPC server report malformed requests
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Allow checks the limits of a call made over another API than HTTP, for example gRPC. method is the name of the
+// JSON-RPC method with the same effect. It returns how long to wait and the reason if the call is rejected,
+// and an empty reason if it is allowed.
+func (l *RateLimiter) Allow(ip string, method string, chainID string) (time.Duration, string) {
+	retryAfter, reason := l.allow(ip, []request{{method: method, chainID: chainID}}, time.Now())
+	if reason != "" {
+		l.logger.Info("Throttled request", "ip", ip, "method", method, "reason", reason)
+	}
+	return retryAfter, reason
+}
+
+// CheckWebsocketCall charges a call received over a websocket connection like a JSON-RPC call over HTTP,
+// against the IP of remoteAddr and the chain ID of its params. It implements jsonrpc.CallFilter.
+func (l *RateLimiter) CheckWebsocketCall(remoteAddr string, req *rpctypes.RPCRequest) error {
+	retryAfter, reason := l.Allow(hostIP(remoteAddr), req.Method, chainIDParam(req.Params))
+	if reason == "" {
+		return nil
+	}
+	return types.NewError(types.CodeTooManyRequests, "%s, retry after %ds", reason, int(math.Ceil(retryAfter.Seconds())))
+}
+
+// allow checks the limits of a request and returns the reason for rejecting it.
+// An empty reason means the request is allowed.
+func (l *RateLimiter) allow(ip string, requests []request, now time.Time) (time.Duration, string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.cleanup(now)
+
+	c, ok := l.clients[ip]
+	if !ok {
+		c = &client{bucket: bucket{tokens: float64(l.config.RateLimitPerIPBurst), last: now}}
+		l.clients[ip] = c
+	}
+
+	if now.Before(c.bannedUntil) {
+		return c.bannedUntil.Sub(now), fmt.Sprintf("%s is banned until %s", ip, c.bannedUntil.UTC().Format(time.RFC3339))
+	}
+
+	cost := len(requests)
+	if cost == 0 {
+		cost = 1
+	}
+	if l.config.RateLimitPerIP > 0 && !c.bucket.take(now, l.config.RateLimitPerIP, l.config.RateLimitPerIPBurst, cost) {
+		l.strike(c, now)
+		return time.Duration(float64(time.Second) / l.config.RateLimitPerIP), fmt.Sprintf("rate limit exceeded for %s", ip)
+	}
+
+	if l.config.RateLimitPerChain > 0 {
+		for _, req := range requests {
+			if !writeMethods[req.method] || req.chainID == "" {
+				continue
+			}
+			b, ok := l.chains[req.chainID]
+			if !ok {
+				b = &bucket{tokens: float64(l.config.RateLimitPerChainBurst), last: now}
+				l.chains[req.chainID] = b
+			}
+			if !b.take(now, l.config.RateLimitPerChain, l.config.RateLimitPerChainBurst, 1) {
+				l.strike(c, now)
+				return time.Duration(float64(time.Second) / l.config.RateLimitPerChain), fmt.Sprintf("rate limit exceeded for chain %s", req.chainID)
+			}
+		}
+	}
+
+	return 0, ""
+}
+
+// strike counts a throttled request against a client and bans it above the threshold. Not thread safe.
+func (l *RateLimiter) strike(c *client, now time.Time) {
+	if l.config.BanThreshold == 0 {
+		return
+	}
+	if now.Sub(c.lastStrike) > l.config.BanDuration {
+		c.strikes = 0
+	}
+	c.strikes++
+	c.lastStrike = now
+	if c.strikes >= l.config.BanThreshold {
+		c.strikes = 0
+		c.bannedUntil = now.Add(l.config.BanDuration)
+	}
+}
+
+// cleanup drops clients and chains that are idle and not banned. Not thread safe.
+func (l *RateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+	for ip, c := range l.clients {
+		if now.Sub(c.bucket.last) > cleanupInterval && now.After(c.bannedUntil) {
+			delete(l.clients, ip)
+		}
+	}
+	for chainID, b := range l.chains {
+		if now.Sub(b.last) > cleanupInterval {
+			delete(l.chains, chainID)
+		}
+	}
+}
+
+// take refills the bucket and removes n tokens if available.
+func (b *bucket) take(now time.Time, rate float64, burst int, n int) bool {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// request is the part of an RPC call the limiter cares about
+type request struct {
+	rpc     *rpctypes.RPCRequest // nil for URI calls
+	method  string
+	chainID string
+}
+
+// bodyError is returned by parseRequests if the request body can't be read, for example because it is too large
+type bodyError struct {
+	error
+}
+
+// parseRequests extracts the method and chain ID of URI, JSON-RPC and REST calls.
+// REST calls are reported with the name of the equivalent JSON-RPC method.
+// The request body is restored, so the RPC server can read it again.
+func parseRequests(r *http.Request) ([]request, error) {
+	if strings.HasPrefix(r.URL.Path, core.RESTPath) {
+		method, chainID := core.RESTMethod(r)
+		return []request{{method: method, chainID: chainID}}, nil
+	}
+	if r.URL.Path != "/" {
+		return []request{{
+			method:  strings.TrimPrefix(r.URL.Path, "/"),
+			chainID: strings.Trim(rpcserver.GetParam(r, "chain_id"), `"`),
+		}}, nil
+	}
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, bodyError{errors.Wrap(err, "error reading request body")}
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	var rpcRequests []rpctypes.RPCRequest
+	if err := json.Unmarshal(body, &rpcRequests); err != nil {
+		var rpcRequest rpctypes.RPCRequest
+		if err := json.Unmarshal(body, &rpcRequest); err != nil {
+			return nil, err
+		}
+		rpcRequests = []rpctypes.RPCRequest{rpcRequest}
+	}
+
+	requests := make([]request, 0, len(rpcRequests))
+	for i := range rpcRequests {
+		rpcRequest := &rpcRequests[i]
+		requests = append(requests, request{
+			rpc:     rpcRequest,
+			method:  rpcRequest.Method,
+			chainID: chainIDParam(rpcRequest.Params),
+		})
+	}
+	return requests, nil
+}
+
+// chainIDParam returns the chain_id of named params, or the first positional param.
+// All RPC methods take the chain ID as their first parameter.
+func chainIDParam(params json.RawMessage) (chainID string) {
+	var named map[string]json.RawMessage
+	if err := json.Unmarshal(params, &named); err == nil {
+		_ = json.Unmarshal(named["chain_id"], &chainID)
+		return
+	}
+	var positional []json.RawMessage
+	if err := json.Unmarshal(params, &positional); err == nil && len(positional) > 0 {
+		_ = json.Unmarshal(positional[0], &chainID)
+	}
+	return
+}
+
+// writeError rejects a request with a REST or a JSON-RPC error. The JSON-RPC response of a single call has its request ID.
+func writeError(w http.ResponseWriter, r *http.Request, status int, requests []request, err *types.Error, logger log.Logger) {
+	if strings.HasPrefix(r.URL.Path, core.RESTPath) {
+		core.WriteRESTError(w, status, err)
+		return
+	}
+	var id interface{} = rpctypes.JSONRPCIntID(-1)
+	if len(requests) == 1 && requests[0].rpc != nil && requests[0].rpc.ID != nil {
+		id = requests[0].rpc.ID
+	}
+	jsonrpc.WriteError(w, status, id, err, logger)
+}
+
+// remoteIP returns the IP part of the remote address
+func remoteIP(r *http.Request) string {
+	return hostIP(r.RemoteAddr)
+}
+
+// hostIP returns the IP part of an address, or the address if it has no port
+func hostIP(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
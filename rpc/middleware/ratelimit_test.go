@@ -0,0 +1,279 @@
+package middleware
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/rpc/core"
+	"director/m/v2/rpc/jsonrpc"
+	"director/m/v2/state"
+	"director/m/v2/store"
+	"director/m/v2/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	amino "github.com/tendermint/go-amino"
+	"github.com/tendermint/tendermint/libs/log"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// newLimitedServer serves the RPC routes of a leader behind a rate limiter with the limits of rpcConfig
+func newLimitedServer(t *testing.T, rpcConfig *config.RPCConfig) *httptest.Server {
+	testnetStore := store.NewStore(dbm.NewMemDB(), map[string]config.TestnetsTOMLConfig{
+		"default": {RequiredValidators: 4},
+		"other":   {RequiredValidators: 4},
+	})
+	logger := log.NewNopLogger()
+	core.SetLogger(logger)
+	core.SetStateMachine(state.NewMachine(testnetStore, logger, time.Second))
+	core.SetLeadership(nil)
+	limiter := NewRateLimiter(rpcConfig, logger)
+
+	cdc := amino.NewCodec()
+	mux := http.NewServeMux()
+	wm := jsonrpc.NewWebsocketManager(core.Routes, cdc, 0)
+	wm.SetLogger(logger)
+	wm.SetCallFilter(limiter.CheckWebsocketCall)
+	mux.HandleFunc("/websocket", wm.WebsocketHandler)
+	mux.HandleFunc(core.FilesPath, core.FileHandler)
+	mux.HandleFunc(core.RESTPath, core.RESTHandler)
+	jsonrpc.RegisterRPCFuncs(mux, core.Routes, cdc, logger)
+	return httptest.NewServer(limiter.Handler(mux))
+}
+
+// newTestLimits returns a config without limits, tests set the limits they check
+func newTestLimits() *config.RPCConfig {
+	rpcConfig := config.DefaultRPCConfig()
+	rpcConfig.RateLimitPerIP = 0
+	rpcConfig.RateLimitPerChain = 0
+	rpcConfig.BanThreshold = 0
+	return rpcConfig
+}
+
+func dialWebsocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial(strings.Replace(server.URL, "http://", "ws://", 1)+"/websocket", nil)
+	require.NoError(t, err)
+	return conn
+}
+
+// The calls of a websocket connection are charged one by one, not only the upgrade of the connection
+func TestWebsocketRegistrationsAreThrottled(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerChain = 0.001
+	rpcConfig.RateLimitPerChainBurst = 2
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+	conn := dialWebsocket(t, server)
+	defer conn.Close() // nolint: errcheck
+
+	for i := 0; i < 2; i++ {
+		response := callWebsocket(t, conn, "register", `{"chain_id": "default", "name": "validator"}`)
+		require.NotNil(t, response.Error)
+		assert.NotEqual(t, types.CodeTooManyRequests, response.Error.Code)
+	}
+	response := callWebsocket(t, conn, "register", `{"chain_id": "default", "name": "validator"}`)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeTooManyRequests, response.Error.Code)
+	assert.Equal(t, float64(1), response.ID)
+	response = callWebsocket(t, conn, "register_json", `["default"]`)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeTooManyRequests, response.Error.Code)
+
+	// Reads and the registrations of other chains are not limited per chain
+	response = callWebsocket(t, conn, "status", `{"chain_id": "default"}`)
+	assert.Nil(t, response.Error)
+	response = callWebsocket(t, conn, "register", `{"chain_id": "other", "name": "validator"}`)
+	require.NotNil(t, response.Error)
+	assert.NotEqual(t, types.CodeTooManyRequests, response.Error.Code)
+}
+
+func TestWebsocketCallsCountAgainstTheIPLimit(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerIP = 0.001
+	rpcConfig.RateLimitPerIPBurst = 3
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+	// the upgrade costs a token
+	conn := dialWebsocket(t, server)
+	defer conn.Close() // nolint: errcheck
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, callWebsocket(t, conn, "testnets", `{}`).Error)
+	}
+	response := callWebsocket(t, conn, "testnets", `{}`)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeTooManyRequests, response.Error.Code)
+
+	// HTTP requests share the limit
+	status, _ := postRPC(t, server.URL, "testnets")
+	assert.Equal(t, http.StatusTooManyRequests, status)
+}
+
+func post(t *testing.T, url string, body string) (*http.Response, jsonrpc.RPCResponse) {
+	res, err := http.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer res.Body.Close() // nolint: errcheck
+	var response jsonrpc.RPCResponse
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+	return res, response
+}
+
+func TestBatchCallsAreCharged(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerIP = 0.001
+	rpcConfig.RateLimitPerIPBurst = 3
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+
+	// A batch over the burst is rejected as a whole
+	res, err := http.Post(server.URL, "application/json", strings.NewReader(`[
+		{"jsonrpc": "2.0", "id": 1, "method": "testnets"},
+		{"jsonrpc": "2.0", "id": 2, "method": "testnets"},
+		{"jsonrpc": "2.0", "id": 3, "method": "testnets"},
+		{"jsonrpc": "2.0", "id": 4, "method": "testnets"}
+	]`))
+	require.NoError(t, err)
+	res.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	res, err = http.Post(server.URL, "application/json", strings.NewReader(`[
+		{"jsonrpc": "2.0", "id": 1, "method": "testnets"},
+		{"jsonrpc": "2.0", "id": 2, "method": "testnets"}
+	]`))
+	require.NoError(t, err)
+	res.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	_, response := post(t, server.URL, `{"jsonrpc": "2.0", "id": 7, "method": "testnets"}`)
+	assert.Nil(t, response.Error)
+
+	// The rejection of a single call has its ID and tells when to retry
+	res, response = post(t, server.URL, `{"jsonrpc": "2.0", "id": 8, "method": "testnets"}`)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.NotEmpty(t, res.Header.Get("Retry-After"))
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeTooManyRequests, response.Error.Code)
+	assert.Equal(t, float64(8), response.ID)
+}
+
+func TestMalformedRequestsAreCharged(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerIP = 0.001
+	rpcConfig.RateLimitPerIPBurst = 1
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+
+	// The RPC server reports the malformed request
+	res, response := post(t, server.URL, `{"jsonrpc": `)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeParseError, response.Error.Code)
+
+	res, response = post(t, server.URL, `{"jsonrpc": `)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeTooManyRequests, response.Error.Code)
+}
+
+func TestBodyLimit(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerIP = 1000
+	rpcConfig.MaxBodyBytes = 64
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+
+	res, response := post(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "testnets", "params": {"padding": "`+strings.Repeat("a", 64)+`"}}`)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.NotNil(t, response.Error)
+	assert.Equal(t, types.CodeInvalidRequest, response.Error.Code)
+}
+
+func TestBans(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerIP = 0.001
+	rpcConfig.RateLimitPerIPBurst = 1
+	rpcConfig.BanThreshold = 2
+	rpcConfig.BanDuration = time.Minute
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+
+	status, _ := postRPC(t, server.URL, "testnets")
+	assert.Equal(t, http.StatusOK, status)
+	for i := 0; i < 2; i++ {
+		_, response := post(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "testnets"}`)
+		require.NotNil(t, response.Error)
+		assert.Contains(t, response.Error.Message, "rate limit exceeded")
+	}
+
+	// The second strike bans the client for BanDuration
+	res, response := post(t, server.URL, `{"jsonrpc": "2.0", "id": 1, "method": "testnets"}`)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	require.NotNil(t, response.Error)
+	assert.Contains(t, response.Error.Message, "is banned until")
+	retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 60, retryAfter, 1)
+}
+
+func TestRESTRegistrationsAreLimitedPerChain(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerChain = 0.001
+	rpcConfig.RateLimitPerChainBurst = 1
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+
+	register := func(chainID string) *http.Response {
+		res, err := http.Post(server.URL+core.RESTPath+"testnets/"+chainID+"/registrations", "application/json", strings.NewReader(`{"name": "validator"}`))
+		require.NoError(t, err)
+		return res
+	}
+	res := register("default")
+	res.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	// REST calls are rejected with REST errors
+	res = register("default")
+	defer res.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	var body struct {
+		Error *types.Error `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+	require.NotNil(t, body.Error)
+	assert.Equal(t, types.CodeTooManyRequests, body.Error.Code)
+	assert.Contains(t, body.Error.Message, "chain default")
+
+	// Reads of the chain and registrations of other chains are not limited per chain
+	get, err := http.Get(server.URL + core.RESTPath + "testnets/default")
+	require.NoError(t, err)
+	get.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusOK, get.StatusCode)
+	res = register("other")
+	res.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestFileDownloadsAreLimitedPerIP(t *testing.T) {
+	rpcConfig := newTestLimits()
+	rpcConfig.RateLimitPerIP = 0.001
+	rpcConfig.RateLimitPerIPBurst = 1
+	server := newLimitedServer(t, rpcConfig)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + core.FilesPath + "default/genesis.json")
+	require.NoError(t, err)
+	res.Body.Close() // nolint: errcheck
+	assert.NotEqual(t, http.StatusTooManyRequests, res.StatusCode)
+
+	res, err = http.Get(server.URL + core.FilesPath + "default/genesis.json.sha256")
+	require.NoError(t, err)
+	defer res.Body.Close() // nolint: errcheck
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.NotEmpty(t, res.Header.Get("Retry-After"))
+}
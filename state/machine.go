@@ -2,9 +2,11 @@ package state
 
 import (
 	"director/m/v2/store"
+	"errors"
 	"github.com/tendermint/tendermint/consensus"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/libs/service"
+	"github.com/tendermint/tendermint/p2p"
 	tmctypes "github.com/tendermint/tendermint/rpc/core/types"
 	"reflect"
 	"runtime/debug"
@@ -18,18 +20,36 @@ var (
 // Machine is the state machine struct definition
 type Machine struct {
 	service.BaseService
-	testnetDB *store.TestnetDB
+	testnetDB store.Store
 
 	// state changes may be triggered by: msgs from peers,
 	// msgs from ourself, or by timeouts
 	peerMsgQueue    chan msgInfo
 	timeoutTicker   TimeoutTicker
 	timeoutInterval time.Duration
+	// closed when the receive routine returned
+	done chan struct{}
+
+	// store snapshots, taken on the heartbeat when snapshotInterval passed since the last one
+	snapshotDir       string
+	snapshotInterval  time.Duration
+	snapshotRetention int
+	lastSnapshot      time.Time
 }
 
 // MachineOption is additional parameters to Machine
 type MachineOption func(*Machine)
 
+// WithSnapshots sets the directory and the retention of store snapshots,
+// and takes a snapshot every interval. An interval of 0 disables periodic snapshots.
+func WithSnapshots(dir string, interval time.Duration, retention int) MachineOption {
+	return func(m *Machine) {
+		m.snapshotDir = dir
+		m.snapshotInterval = interval
+		m.snapshotRetention = retention
+	}
+}
+
 // msgs from the reactor which may update the state
 type msgInfo struct {
 	Msg consensus.Message `json:"msg"`
@@ -41,12 +61,13 @@ type timeoutInfo struct {
 }
 
 // NewMachine returns a new state machine object
-func NewMachine(testnetDB *store.TestnetDB, logger log.Logger, timeoutInterval time.Duration, options ...MachineOption) *Machine {
+func NewMachine(testnetDB store.Store, logger log.Logger, timeoutInterval time.Duration, options ...MachineOption) *Machine {
 	m := &Machine{
 		testnetDB:       testnetDB,
 		peerMsgQueue:    make(chan msgInfo, msgQueueSize),
 		timeoutTicker:   NewTimeoutTicker(),
 		timeoutInterval: timeoutInterval,
+		lastSnapshot:    time.Now(),
 	}
 	m.BaseService = *service.NewBaseService(logger, "StateMachine", m)
 	m.timeoutTicker.SetLogger(logger)
@@ -63,6 +84,11 @@ func (m *Machine) OnStart() error {
 		return err
 	}
 
+	// Bring the testnets up to date before the RPC server starts, for example open new recurring testnets
+	if err := m.testnetDB.GlobalStateCheck(); err != nil {
+		m.Logger.Error("Error with the first state check", "err", err)
+	}
+
 	// Todo: Make the smallest common denominator of all testnets that need a timer the next timeout.
 	//for chainID, duration := range m.testnetDB.GetTimedTestnets() {
 	//	m.timeoutTicker.ScheduleTimeout(timeoutInfo{
@@ -71,10 +97,11 @@ func (m *Machine) OnStart() error {
 	//	})
 	//}
 	m.timeoutTicker.ScheduleTimeout(timeoutInfo{
-		Duration: 0,
+		Duration: m.timeoutInterval,
 	})
 
-	go m.receiveRoutine()
+	m.done = make(chan struct{})
+	go m.receiveRoutine(m.Quit())
 
 	m.Logger.Info("State machine started")
 	return nil
@@ -86,7 +113,20 @@ func (m *Machine) OnStop() {
 	m.Logger.Info("State machine stopped")
 }
 
-func (m *Machine) receiveRoutine() {
+// OnReset implements service.Service. A director that lost the leadership resets its state machine,
+// so it can start it again when it is elected again.
+func (m *Machine) OnReset() error {
+	// The receive routine uses the ticker until it returns
+	if m.done != nil {
+		<-m.done
+	}
+	m.timeoutTicker = NewTimeoutTicker()
+	m.timeoutTicker.SetLogger(m.Logger)
+	return nil
+}
+
+func (m *Machine) receiveRoutine(quit <-chan struct{}) {
+	defer close(m.done)
 	defer func() {
 		if r := recover(); r != nil {
 			m.Logger.Error("StateMachine failure", "err", r, "stack", string(debug.Stack()))
@@ -102,7 +142,7 @@ func (m *Machine) receiveRoutine() {
 			m.handleMsg(mi)
 		case ti := <-m.timeoutTicker.Chan(): // tockChan:
 			m.handleTimeout(ti)
-		case <-m.Quit():
+		case <-quit:
 			return
 		}
 	}
@@ -126,6 +166,13 @@ func (m *Machine) handleMsg(mi msgInfo) {
 	case *GlobalCheckAndSetState:
 		// Coming from the Timer, when all testnet states should be checked for timeout.
 		err = m.testnetDB.GlobalStateCheck()
+	case *TakeSnapshot:
+		// Coming from the Timer, when the snapshot interval passed.
+		var path string
+		path, err = m.Snapshot()
+		if err == nil {
+			m.Logger.Info("Snapshot taken", "path", path)
+		}
 	default:
 		m.Logger.Error("Unknown msg type", "type", reflect.TypeOf(msg))
 		return
@@ -141,6 +188,10 @@ func (m *Machine) handleMsg(mi msgInfo) {
 func (m *Machine) handleTimeout(ti timeoutInfo) {
 	m.Logger.Debug("Received tock", "timeout", ti.Duration)
 	m.SendMessage(&GlobalCheckAndSetState{})
+	if m.snapshotInterval > 0 && time.Since(m.lastSnapshot) >= m.snapshotInterval {
+		m.lastSnapshot = time.Now()
+		m.SendMessage(&TakeSnapshot{})
+	}
 	m.timeoutTicker.ScheduleTimeout(timeoutInfo{
 		Duration: m.timeoutInterval,
 	})
@@ -165,3 +216,51 @@ func (m *Machine) GetGenesis(chainID string) (*tmctypes.ResultGenesis, error) {
 func (m *Machine) GetAddressBook(chainID string) (*store.AddrBookJSON, error) {
 	return m.testnetDB.GetAddressBook(chainID)
 }
+
+// GetPeers returns the peer addresses of a testnet from the state machine database struct
+func (m *Machine) GetPeers(chainID string, options store.PeersOptions) ([]string, error) {
+	return m.testnetDB.GetPeers(chainID, options)
+}
+
+// GetNodeConfig returns the Tendermint configuration of a node from the state machine database struct
+func (m *Machine) GetNodeConfig(chainID string, pubKey string, nodeID p2p.ID) (*store.NodeConfig, error) {
+	return m.testnetDB.GetNodeConfig(chainID, pubKey, nodeID)
+}
+
+// GetArtifact returns an extra file of the genesis compiler from the state machine database struct
+func (m *Machine) GetArtifact(chainID string, name string) ([]byte, error) {
+	return m.testnetDB.GetArtifact(chainID, name)
+}
+
+// ArchiveTestnet archives a testnet in the state machine database struct and returns the chain ID of the next instance, if any
+func (m *Machine) ArchiveTestnet(chainID string) (string, error) {
+	return m.testnetDB.ArchiveTestnet(chainID)
+}
+
+// ReopenTestnet reopens a failed testnet in the state machine database struct
+func (m *Machine) ReopenTestnet(chainID string) error {
+	return m.testnetDB.ReopenTestnet(chainID)
+}
+
+// ListTestnets returns the chain IDs of all testnets from the state machine database struct
+func (m *Machine) ListTestnets() []string {
+	return m.testnetDB.ListTestnets()
+}
+
+// GetStatus returns the registration progress of a testnet from the state machine database struct
+func (m *Machine) GetStatus(chainID string) (*store.TestnetStatus, error) {
+	return m.testnetDB.GetStatus(chainID)
+}
+
+// SelectValidator picks or drops a registration for the genesis of a testnet in the state machine database struct
+func (m *Machine) SelectValidator(chainID string, pubKey string, selected bool) error {
+	return m.testnetDB.SelectValidator(chainID, pubKey, selected)
+}
+
+// Snapshot writes a snapshot of the state machine database struct and returns its path
+func (m *Machine) Snapshot() (string, error) {
+	if m.snapshotDir == "" {
+		return "", errors.New("snapshots are not configured")
+	}
+	return m.testnetDB.Snapshot(m.snapshotDir, m.snapshotRetention)
+}
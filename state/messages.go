@@ -27,6 +27,15 @@ func (c *GlobalCheckAndSetState) ValidateBasic() error {
 	return nil
 }
 
+// TakeSnapshot is sent when a periodic snapshot of the store is due
+type TakeSnapshot struct {
+}
+
+// ValidateBasic validates a TakeSnapshot message
+func (c *TakeSnapshot) ValidateBasic() error {
+	return nil
+}
+
 // RegisterValidator is sent when a new validator registers itself
 type RegisterValidator struct {
 	ChainID   string
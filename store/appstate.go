@@ -0,0 +1,176 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+)
+
+// GenesisAccount is the account allocation of a registered node
+type GenesisAccount struct {
+	// Account address of the registrant
+	Address string
+	// Token allocation in the "1000stake,5token" format
+	Coins string
+	// Optional signed genesis transaction
+	GenTx json.RawMessage
+}
+
+// AppStateBuilder merges the accounts of the registrants into the app_state of a genesis file
+type AppStateBuilder interface {
+	BuildAppState(appState json.RawMessage, accounts []GenesisAccount) (json.RawMessage, error)
+}
+
+// appStateBuilders lists the builders selectable by the app_state_builder testnet option
+var appStateBuilders = map[string]AppStateBuilder{
+	"cosmos-sdk": CosmosSDKAppStateBuilder{},
+}
+
+// RegisterAppStateBuilder makes an AppStateBuilder selectable by name in the testnet config.
+// It has to be called before the store is created.
+func RegisterAppStateBuilder(name string, builder AppStateBuilder) {
+	appStateBuilders[name] = builder
+	config.RegisterAppStateBuilderName(name)
+}
+
+// GetAppStateBuilder returns the AppStateBuilder registered under name
+func GetAppStateBuilder(name string) (AppStateBuilder, error) {
+	builder, ok := appStateBuilders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown app state builder %s", name)
+	}
+	return builder, nil
+}
+
+////////////////////////////////////////////////////////////////
+// Cosmos-SDK
+////////////////////////////////////////////////////////////////
+
+// CosmosSDKAppStateBuilder adds genesis accounts to the auth module and gentxs to the genutil module,
+// the same way `add-genesis-account` and `collect-gentxs` of Cosmos-SDK applications do.
+type CosmosSDKAppStateBuilder struct{}
+
+type cosmosCoin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+type cosmosAccount struct {
+	Address       string          `json:"address"`
+	Coins         []cosmosCoin    `json:"coins"`
+	PublicKey     json.RawMessage `json:"public_key"`
+	AccountNumber string          `json:"account_number"`
+	Sequence      string          `json:"sequence"`
+}
+
+type cosmosTypedAccount struct {
+	Type  string        `json:"type"`
+	Value cosmosAccount `json:"value"`
+}
+
+// BuildAppState implements AppStateBuilder
+func (CosmosSDKAppStateBuilder) BuildAppState(appState json.RawMessage, accounts []GenesisAccount) (json.RawMessage, error) {
+	modules := map[string]json.RawMessage{}
+	if len(appState) > 0 {
+		if err := json.Unmarshal(appState, &modules); err != nil {
+			return nil, err
+		}
+	}
+
+	auth := map[string]json.RawMessage{}
+	if err := unmarshalModule(modules, "auth", &auth); err != nil {
+		return nil, err
+	}
+	var authAccounts []cosmosTypedAccount
+	if err := unmarshalModule(auth, "accounts", &authAccounts); err != nil {
+		return nil, err
+	}
+	genutil := map[string]json.RawMessage{}
+	if err := unmarshalModule(modules, "genutil", &genutil); err != nil {
+		return nil, err
+	}
+	var genTxs []json.RawMessage
+	if err := unmarshalModule(genutil, "gentxs", &genTxs); err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	for _, account := range authAccounts {
+		existing[account.Value.Address] = true
+	}
+	for _, account := range accounts {
+		if account.Address != "" && !existing[account.Address] {
+			coins, err := parseCoins(account.Coins)
+			if err != nil {
+				return nil, err
+			}
+			authAccounts = append(authAccounts, cosmosTypedAccount{
+				Type: "cosmos-sdk/Account",
+				Value: cosmosAccount{
+					Address:       account.Address,
+					Coins:         coins,
+					PublicKey:     json.RawMessage("null"),
+					AccountNumber: "0",
+					Sequence:      "0",
+				},
+			})
+			existing[account.Address] = true
+		}
+		if len(account.GenTx) > 0 {
+			genTxs = append(genTxs, account.GenTx)
+		}
+	}
+
+	if err := marshalModule(auth, "accounts", authAccounts); err != nil {
+		return nil, err
+	}
+	if err := marshalModule(modules, "auth", auth); err != nil {
+		return nil, err
+	}
+	if err := marshalModule(genutil, "gentxs", genTxs); err != nil {
+		return nil, err
+	}
+	if err := marshalModule(modules, "genutil", genutil); err != nil {
+		return nil, err
+	}
+	return json.Marshal(modules)
+}
+
+// unmarshalModule decodes modules[name] into v, if present
+func unmarshalModule(modules map[string]json.RawMessage, name string, v interface{}) error {
+	raw, ok := modules[name]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid app_state %s: %v", name, err)
+	}
+	return nil
+}
+
+// marshalModule encodes v into modules[name]
+func marshalModule(modules map[string]json.RawMessage, name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	modules[name] = raw
+	return nil
+}
+
+// parseCoins parses a "1000stake,5token" coin list, sorted by denomination as the Cosmos-SDK requires
+func parseCoins(coinsStr string) ([]cosmosCoin, error) {
+	parsed, err := types.ParseCoins(coinsStr)
+	if err != nil {
+		return nil, err
+	}
+	coins := make([]cosmosCoin, 0, len(parsed))
+	for _, coin := range parsed {
+		coins = append(coins, cosmosCoin{
+			Denom:  coin.Denom,
+			Amount: coin.Amount,
+		})
+	}
+	return coins, nil
+}
@@ -0,0 +1,550 @@
+package store
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"github.com/tendermint/tendermint/crypto/sr25519"
+	tmos "github.com/tendermint/tendermint/libs/os"
+	tmctypes "github.com/tendermint/tendermint/rpc/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// backend persists the testnets of a TestnetDB
+type backend interface {
+	KeySpace
+	// view calls fn with a loader that sees the testnets as they were saved at a single point in time,
+	// even if another process saves testnets meanwhile
+	view(fn func(load loader) error) error
+	// save writes the changed testnets, by chain ID, all or none of them, and flushes them to disk before returning
+	save(changes map[string]*testnetChange) error
+}
+
+// loader returns a saved testnet, or nil if the chain ID was never saved
+type loader func(chainID string) (*TestnetConfig, error)
+
+// testnetChange is a testnet changed by a transition, with the parts of it that changed.
+// Backends that store a testnet as a whole can ignore the parts.
+type testnetChange struct {
+	testnet *TestnetConfig
+	// The testnet changed besides its registrations
+	fields bool
+	// Public keys of the changed registrations, every registration changed if allValidators is set
+	validators    map[string]bool
+	allValidators bool
+}
+
+////////////////////////////////////////////////////////////////
+// tm-db
+////////////////////////////////////////////////////////////////
+
+// Keys of the tm-db backend. A testnet without its registrations is stored under testnetKeyPrefix and the chain ID,
+// and every registration under validatorKeyPrefix, the chain ID and the public key, so a registration writes
+// its own key and the testnet without the other registrations. Chain IDs are escaped, so one chain ID can't be
+// the prefix of another one.
+const (
+	testnetKeyPrefix   = "testnets/"
+	validatorKeyPrefix = "validators/"
+)
+
+func testnetKey(chainID string) []byte {
+	return []byte(testnetKeyPrefix + url.PathEscape(chainID))
+}
+
+func validatorKeys(chainID string) []byte {
+	return []byte(validatorKeyPrefix + url.PathEscape(chainID) + "/")
+}
+
+func validatorKey(chainID string, pubKey string) []byte {
+	return append(validatorKeys(chainID), pubKey...)
+}
+
+// dbBackend stores gob encoded testnets and registrations in a tm-db database.
+// Databases written by older versions keep every testnet as a whole under its chain ID. These testnets are
+// still loaded, and are moved to the current keys the first time they change.
+type dbBackend struct {
+	db dbm.DB
+}
+
+// view implements backend. Only one process can open the database, so nobody saves while fn runs.
+func (b *dbBackend) view(fn func(load loader) error) error {
+	return fn(b.load)
+}
+
+func (b *dbBackend) load(chainID string) (*TestnetConfig, error) {
+	testnetconfigbytearray, err := b.db.Get(testnetKey(chainID))
+	if err != nil {
+		return nil, err
+	}
+	if testnetconfigbytearray == nil {
+		// Older versions
+		testnetconfigbytearray, err = b.db.Get([]byte(chainID))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if testnetconfigbytearray == nil {
+		return nil, nil
+	}
+	var result *TestnetConfig
+	if err := gobDecode(testnetconfigbytearray, &result); err != nil {
+		return nil, err
+	}
+	if result.Validators == nil {
+		result.Validators = map[string]*ValidatorConfig{}
+	}
+
+	it, err := dbm.IteratePrefix(b.db, validatorKeys(chainID))
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	for ; it.Valid(); it.Next() {
+		var validator *ValidatorConfig
+		if err := gobDecode(it.Value(), &validator); err != nil {
+			return nil, err
+		}
+		result.Validators[validator.PubKey] = validator
+	}
+	return result, nil
+}
+
+// save writes the changed parts of the testnets in a single batch that is synced to disk
+func (b *dbBackend) save(changes map[string]*testnetChange) error {
+	batch := b.db.NewBatch()
+	defer batch.Close()
+	for chainID, change := range changes {
+		saved, err := b.db.Has(testnetKey(chainID))
+		if err != nil {
+			return err
+		}
+		legacy := false
+		if !saved {
+			if legacy, err = b.db.Has([]byte(chainID)); err != nil {
+				return err
+			}
+		}
+		if legacy {
+			batch.Delete([]byte(chainID))
+		}
+		if change.fields || !saved {
+			fields := *change.testnet
+			fields.Validators = nil
+			value, err := gobEncode(&fields)
+			if err != nil {
+				return err
+			}
+			batch.Set(testnetKey(chainID), value)
+		}
+		if change.allValidators || legacy {
+			if err := b.deleteValidators(batch, chainID, change.testnet); err != nil {
+				return err
+			}
+			for pubKey, validator := range change.testnet.Validators {
+				if err := setValidator(batch, chainID, pubKey, validator); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for pubKey := range change.validators {
+			if err := setValidator(batch, chainID, pubKey, change.testnet.Validators[pubKey]); err != nil {
+				return err
+			}
+		}
+	}
+	return batch.WriteSync()
+}
+
+// deleteValidators deletes the saved registrations of a testnet that it doesn't have anymore
+func (b *dbBackend) deleteValidators(batch dbm.Batch, chainID string, testnet *TestnetConfig) error {
+	it, err := dbm.IteratePrefix(b.db, validatorKeys(chainID))
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	prefix := len(validatorKeys(chainID))
+	for ; it.Valid(); it.Next() {
+		if _, ok := testnet.Validators[string(it.Key()[prefix:])]; !ok {
+			batch.Delete(append([]byte{}, it.Key()...))
+		}
+	}
+	return nil
+}
+
+// setValidator writes a registration, or deletes it if it's nil
+func setValidator(batch dbm.Batch, chainID string, pubKey string, validator *ValidatorConfig) error {
+	if validator == nil {
+		batch.Delete(validatorKey(chainID, pubKey))
+		return nil
+	}
+	value, err := gobEncode(validator)
+	if err != nil {
+		return err
+	}
+	batch.Set(validatorKey(chainID, pubKey), value)
+	return nil
+}
+
+// Format implements KeySpace. Values are gob encoded testnets and registrations.
+func (b *dbBackend) Format() string {
+	return "gob"
+}
+
+// Export implements KeySpace
+func (b *dbBackend) Export() (map[string][]byte, error) {
+	it, err := b.db.Iterator(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+	entries := map[string][]byte{}
+	for ; it.Valid(); it.Next() {
+		entries[string(it.Key())] = append([]byte{}, it.Value()...)
+	}
+	return entries, nil
+}
+
+// Import implements KeySpace. The keys are replaced in a single batch that is synced to disk.
+func (b *dbBackend) Import(entries map[string][]byte) error {
+	existing, err := b.Export()
+	if err != nil {
+		return err
+	}
+	batch := b.db.NewBatch()
+	defer batch.Close()
+	for key := range existing {
+		if _, ok := entries[key]; !ok {
+			batch.Delete([]byte(key))
+		}
+	}
+	for key, value := range entries {
+		batch.Set([]byte(key), value)
+	}
+	return batch.WriteSync()
+}
+
+func gobEncode(value interface{}) ([]byte, error) {
+	registerGobTypes()
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func gobDecode(value []byte, result interface{}) error {
+	registerGobTypes()
+	return gob.NewDecoder(bytes.NewReader(value)).Decode(result)
+}
+
+// registerGobTypes registers the concrete public key types stored in genesis files
+func registerGobTypes() {
+	gob.Register(ed25519.PubKeyEd25519{})
+	gob.Register(secp256k1.PubKeySecp256k1{})
+	gob.Register(sr25519.PubKeySr25519{})
+}
+
+////////////////////////////////////////////////////////////////
+// JSON files
+////////////////////////////////////////////////////////////////
+
+// jsonBackend stores every testnet in a <chain_id>.json file of a directory, to make the data easy to inspect.
+//
+// A batch of changed files is first written to a journal file, which is renamed into place atomically,
+// and then to the testnet files. The journal of the latest batch is kept: readers overlay it over the testnet
+// files, so they never see a batch half written, and the next batch finishes writing it if the process
+// stopped before it was done.
+type jsonBackend struct {
+	dir string
+	// Sequence number of the latest journal this process wrote to the testnet files
+	applied uint64
+}
+
+// journalFile is the name of the journal in the directory of the JSON backend. It is not a .json file,
+// so it can't be mistaken for a testnet.
+const journalFile = "journal"
+
+// maxViewAttempts limits how many times a read is repeated because a batch was saved while reading
+const maxViewAttempts = 10
+
+// journal is a batch of changed testnet files
+type journal struct {
+	Seq uint64 `json:"seq"`
+	// New content of the testnet files by chain ID, null for removed files
+	Testnets map[string]json.RawMessage `json:"testnets"`
+}
+
+// testnetJSON is the file format of a testnet. The genesis is encoded the same way as genesis.json.
+type testnetJSON struct {
+	*TestnetConfig
+	Genesis json.RawMessage `json:"genesis,omitempty"`
+}
+
+func (b *jsonBackend) path(chainID string) string {
+	return filepath.Join(b.dir, url.PathEscape(chainID)+".json")
+}
+
+// view implements backend
+func (b *jsonBackend) view(fn func(load loader) error) error {
+	return b.consistent(func(latest *journal) error {
+		return fn(func(chainID string) (*TestnetConfig, error) {
+			content, err := b.readFile(latest, chainID)
+			if content == nil || err != nil {
+				return nil, err
+			}
+			return decodeTestnetJSON(content)
+		})
+	})
+}
+
+// consistent calls read with the latest journal until no batch was saved while it ran
+func (b *jsonBackend) consistent(read func(latest *journal) error) error {
+	for attempt := 1; ; attempt++ {
+		before, err := b.readJournal()
+		if err != nil {
+			return err
+		}
+		readErr := read(before)
+		after, err := b.readJournal()
+		if err != nil {
+			return err
+		}
+		if before.Seq == after.Seq {
+			return readErr
+		}
+		if attempt == maxViewAttempts {
+			return fmt.Errorf("the testnets in %s kept changing while they were read", b.dir)
+		}
+	}
+}
+
+// readFile returns the content of a testnet file, taking the latest journal into account.
+// It returns nil if the file does not exist.
+func (b *jsonBackend) readFile(latest *journal, chainID string) ([]byte, error) {
+	if content, ok := latest.Testnets[chainID]; ok {
+		if isNull(content) {
+			return nil, nil
+		}
+		return content, nil
+	}
+	content, err := ioutil.ReadFile(b.path(chainID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return content, err
+}
+
+func decodeTestnetJSON(content []byte) (*TestnetConfig, error) {
+	file := testnetJSON{TestnetConfig: &TestnetConfig{}}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, err
+	}
+	if len(file.Genesis) > 0 {
+		genesis := &tmtypes.GenesisDoc{}
+		if err := tmtypes.GetCodec().UnmarshalJSON(file.Genesis, genesis); err != nil {
+			return nil, err
+		}
+		file.TestnetConfig.Genesis = &tmctypes.ResultGenesis{Genesis: genesis}
+	}
+	return file.TestnetConfig, nil
+}
+
+func encodeTestnetJSON(testnetconfig *TestnetConfig) ([]byte, error) {
+	file := testnetJSON{TestnetConfig: testnetconfig}
+	if testnetconfig.Genesis != nil {
+		genesis, err := tmtypes.GetCodec().MarshalJSON(testnetconfig.Genesis.Genesis)
+		if err != nil {
+			return nil, err
+		}
+		file.Genesis = genesis
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// save implements backend
+func (b *jsonBackend) save(changes map[string]*testnetChange) error {
+	files := make(map[string]json.RawMessage, len(changes))
+	for chainID, change := range changes {
+		content, err := encodeTestnetJSON(change.testnet)
+		if err != nil {
+			return err
+		}
+		files[chainID] = content
+	}
+	return b.commit(files)
+}
+
+// commit writes a batch of testnet files, null content removes a file. The batch is saved once its journal
+// is written. Only one process may commit at a time.
+func (b *jsonBackend) commit(files map[string]json.RawMessage) error {
+	previous, err := b.readJournal()
+	if err != nil {
+		return err
+	}
+	// Finish the previous batch, in case its process stopped while writing it
+	if previous.Seq != b.applied {
+		if err := b.apply(previous); err != nil {
+			return err
+		}
+	}
+
+	next := &journal{Seq: previous.Seq + 1, Testnets: files}
+	content, err := json.Marshal(next)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(b.dir, journalFile), content); err != nil {
+		return err
+	}
+	if err := b.apply(next); err != nil {
+		return err
+	}
+	b.applied = next.Seq
+	return nil
+}
+
+// apply writes the files of a journal
+func (b *jsonBackend) apply(batch *journal) error {
+	for chainID, content := range batch.Testnets {
+		if isNull(content) {
+			if err := os.Remove(b.path(chainID)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err := writeFileAtomic(b.path(chainID), content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readJournal returns the journal of the latest batch, or an empty journal if nothing was saved yet
+func (b *jsonBackend) readJournal() (*journal, error) {
+	content, err := ioutil.ReadFile(filepath.Join(b.dir, journalFile))
+	if os.IsNotExist(err) {
+		return &journal{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := &journal{}
+	if err := json.Unmarshal(content, result); err != nil {
+		return nil, fmt.Errorf("invalid journal in %s: %v", b.dir, err)
+	}
+	return result, nil
+}
+
+// isNull returns true for the content of a removed file in a journal
+func isNull(content json.RawMessage) bool {
+	return len(content) == 0 || string(content) == "null"
+}
+
+// Format implements KeySpace. Values are the content of the testnet files.
+func (b *jsonBackend) Format() string {
+	return "json"
+}
+
+// Export implements KeySpace
+func (b *jsonBackend) Export() (entries map[string][]byte, err error) {
+	err = b.consistent(func(latest *journal) error {
+		entries = map[string][]byte{}
+		files, err := ioutil.ReadDir(b.dir)
+		if err != nil {
+			return err
+		}
+		chainIDs := make([]string, 0, len(files)+len(latest.Testnets))
+		for _, file := range files {
+			if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+				continue
+			}
+			chainID, err := url.PathUnescape(strings.TrimSuffix(file.Name(), ".json"))
+			if err != nil {
+				return err
+			}
+			chainIDs = append(chainIDs, chainID)
+		}
+		for chainID := range latest.Testnets {
+			chainIDs = append(chainIDs, chainID)
+		}
+		for _, chainID := range chainIDs {
+			content, err := b.readFile(latest, chainID)
+			if err != nil {
+				return err
+			}
+			if content != nil {
+				entries[chainID] = content
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Import implements KeySpace. The files are replaced in a single batch.
+func (b *jsonBackend) Import(entries map[string][]byte) error {
+	existing, err := b.Export()
+	if err != nil {
+		return err
+	}
+	files := make(map[string]json.RawMessage, len(entries)+len(existing))
+	for chainID := range existing {
+		files[chainID] = nil
+	}
+	for chainID, content := range entries {
+		if !json.Valid(content) {
+			return fmt.Errorf("invalid testnet file for %s", chainID)
+		}
+		files[chainID] = content
+	}
+	return b.commit(files)
+}
+
+// writeFileAtomic writes a file through a synced temporary file, so readers never see a partial write
+func writeFileAtomic(path string, content []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // nolint: errcheck
+	if _, err := f.Write(content); err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir flushes a directory, so a file renamed into it survives a crash
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close() // nolint: errcheck
+	return d.Sync()
+}
+
+// ensureDir creates the directory of the JSON backend
+func (b *jsonBackend) ensureDir() error {
+	return tmos.EnsureDir(b.dir, 0700)
+}
@@ -0,0 +1,212 @@
+package store
+
+import (
+	"director/m/v2/types"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+)
+
+func newTestJSONBackend(t *testing.T) (*jsonBackend, func()) {
+	dir, err := ioutil.TempDir("", "director-json-backend")
+	require.NoError(t, err)
+	b := &jsonBackend{dir: dir}
+	require.NoError(t, b.ensureDir())
+	return b, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+// loadAll loads testnets through a single view of the backend
+func loadAll(t *testing.T, b backend, chainIDs ...string) map[string]*TestnetConfig {
+	testnets := map[string]*TestnetConfig{}
+	require.NoError(t, b.view(func(load loader) error {
+		for _, chainID := range chainIDs {
+			testnet, err := load(chainID)
+			if err != nil {
+				return err
+			}
+			testnets[chainID] = testnet
+		}
+		return nil
+	}))
+	return testnets
+}
+
+// saveTestnets saves whole testnets
+func saveTestnets(b backend, testnets map[string]*TestnetConfig) error {
+	changes := make(map[string]*testnetChange, len(testnets))
+	for chainID, testnet := range testnets {
+		changes[chainID] = &testnetChange{testnet: testnet, fields: true, allValidators: true}
+	}
+	return b.save(changes)
+}
+
+func testTestnet(state types.ServerState) *TestnetConfig {
+	return &TestnetConfig{State: state, Validators: map[string]*ValidatorConfig{}}
+}
+
+func TestJSONBackendSaveAndView(t *testing.T) {
+	b, cleanup := newTestJSONBackend(t)
+	defer cleanup()
+
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": testTestnet(types.Gather), "b/1": testTestnet(types.Serve)}))
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": testTestnet(types.Archived)}))
+
+	testnets := loadAll(t, b, "a", "b/1", "c")
+	assert.Equal(t, types.Archived, testnets["a"].State)
+	assert.Equal(t, types.Serve, testnets["b/1"].State)
+	assert.Nil(t, testnets["c"])
+
+	// A new process sees the same testnets
+	testnets = loadAll(t, &jsonBackend{dir: b.dir}, "a", "b/1")
+	assert.Equal(t, types.Archived, testnets["a"].State)
+	assert.Equal(t, types.Serve, testnets["b/1"].State)
+}
+
+func TestJSONBackendInterruptedBatch(t *testing.T) {
+	b, cleanup := newTestJSONBackend(t)
+	defer cleanup()
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": testTestnet(types.Gather), "b": testTestnet(types.Gather)}))
+
+	// The process stops after writing the journal of a batch, before the testnet files
+	a, err := encodeTestnetJSON(testTestnet(types.Archived))
+	require.NoError(t, err)
+	b2, err := encodeTestnetJSON(testTestnet(types.Gather))
+	require.NoError(t, err)
+	content, err := json.Marshal(journal{Seq: 2, Testnets: map[string]json.RawMessage{"a": a, "b2": b2}})
+	require.NoError(t, err)
+	require.NoError(t, writeFileAtomic(filepath.Join(b.dir, journalFile), content))
+
+	// Readers see the whole batch
+	restarted := &jsonBackend{dir: b.dir}
+	testnets := loadAll(t, restarted, "a", "b", "b2")
+	assert.Equal(t, types.Archived, testnets["a"].State)
+	assert.Equal(t, types.Gather, testnets["b"].State)
+	require.NotNil(t, testnets["b2"])
+
+	// The next batch finishes the interrupted one before its own journal replaces it
+	require.NoError(t, saveTestnets(restarted, map[string]*TestnetConfig{"b": testTestnet(types.Serve)}))
+	journal, err := restarted.readJournal()
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, journal.Seq)
+	require.NoError(t, os.Remove(filepath.Join(b.dir, journalFile)))
+	testnets = loadAll(t, &jsonBackend{dir: b.dir}, "a", "b", "b2")
+	assert.Equal(t, types.Archived, testnets["a"].State)
+	assert.Equal(t, types.Serve, testnets["b"].State)
+	require.NotNil(t, testnets["b2"])
+}
+
+func TestJSONBackendViewRetriesOnConcurrentSave(t *testing.T) {
+	b, cleanup := newTestJSONBackend(t)
+	defer cleanup()
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": testTestnet(types.Gather), "b": testTestnet(types.Gather)}))
+
+	// Another process saves a batch after the reader loaded a, but before it loaded b
+	writer := &jsonBackend{dir: b.dir}
+	attempts := 0
+	var states []types.ServerState
+	require.NoError(t, b.view(func(load loader) error {
+		attempts++
+		states = nil
+		for _, chainID := range []string{"a", "b"} {
+			testnet, err := load(chainID)
+			if err != nil {
+				return err
+			}
+			states = append(states, testnet.State)
+			if attempts == 1 && chainID == "a" {
+				require.NoError(t, saveTestnets(writer, map[string]*TestnetConfig{"a": testTestnet(types.Serve), "b": testTestnet(types.Serve)}))
+			}
+		}
+		return nil
+	}))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, []types.ServerState{types.Serve, types.Serve}, states)
+}
+
+func TestJSONBackendImportReplacesEverything(t *testing.T) {
+	b, cleanup := newTestJSONBackend(t)
+	defer cleanup()
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": testTestnet(types.Gather), "b": testTestnet(types.Gather)}))
+	entries, err := b.Export()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	c, err := encodeTestnetJSON(testTestnet(types.Serve))
+	require.NoError(t, err)
+	require.NoError(t, b.Import(map[string][]byte{"a": entries["a"], "c": c}))
+	entries, err = b.Export()
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Contains(t, entries, "a")
+	assert.Contains(t, entries, "c")
+	_, err = os.Stat(b.path("b"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, b.Import(map[string][]byte{"a": []byte("{")}))
+}
+
+func TestDBBackendWritesOnlyChangedRegistrations(t *testing.T) {
+	db := dbm.NewMemDB()
+	b := &dbBackend{db: db}
+	testnet := testTestnet(types.Gather)
+	testnet.Validators["x"] = &ValidatorConfig{PubKey: "x", Name: "x"}
+	// The first change of a testnet saves it, even if only a registration changed
+	require.NoError(t, b.save(map[string]*testnetChange{"a": {testnet: testnet, validators: map[string]bool{"x": true}}}))
+	require.Contains(t, loadAll(t, b, "a")["a"].Validators, "x")
+	testnet.Validators["y"] = &ValidatorConfig{PubKey: "y", Name: "y"}
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": testnet, "a/b": testTestnet(types.Gather)}))
+
+	// A registration only writes its own key
+	fields, err := db.Get(testnetKey("a"))
+	require.NoError(t, err)
+	require.NoError(t, db.Set(validatorKey("a", "x"), []byte("not written")))
+	next := testnet.copy()
+	next.Validators["z"] = &ValidatorConfig{PubKey: "z", Name: "z"}
+	require.NoError(t, b.save(map[string]*testnetChange{"a": {testnet: next, validators: map[string]bool{"z": true}}}))
+	value, err := db.Get(testnetKey("a"))
+	require.NoError(t, err)
+	assert.Equal(t, fields, value)
+	value, err = db.Get(validatorKey("a", "x"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("not written"), value)
+
+	// Writing all registrations removes the dropped ones
+	require.NoError(t, db.Delete(validatorKey("a", "x")))
+	delete(next.Validators, "y")
+	require.NoError(t, saveTestnets(b, map[string]*TestnetConfig{"a": next}))
+	testnets := loadAll(t, b, "a", "a/b")
+	assert.Len(t, testnets["a"].Validators, 2)
+	assert.Contains(t, testnets["a"].Validators, "x")
+	assert.Contains(t, testnets["a"].Validators, "z")
+	assert.Empty(t, testnets["a/b"].Validators)
+}
+
+func TestDBBackendMovesLegacyTestnets(t *testing.T) {
+	db := dbm.NewMemDB()
+	b := &dbBackend{db: db}
+	// Older versions stored the whole testnet under its chain ID
+	testnet := testTestnet(types.Gather)
+	testnet.Validators["x"] = &ValidatorConfig{PubKey: "x", Name: "x"}
+	value, err := gobEncode(testnet)
+	require.NoError(t, err)
+	require.NoError(t, db.Set([]byte("a"), value))
+
+	testnets := loadAll(t, b, "a")
+	require.Contains(t, testnets["a"].Validators, "x")
+
+	next := testnets["a"].copy()
+	next.Validators["y"] = &ValidatorConfig{PubKey: "y", Name: "y"}
+	require.NoError(t, b.save(map[string]*testnetChange{"a": {testnet: next, validators: map[string]bool{"y": true}}}))
+	has, err := db.Has([]byte("a"))
+	require.NoError(t, err)
+	assert.False(t, has)
+	testnets = loadAll(t, b, "a")
+	assert.Equal(t, types.Gather, testnets["a"].State)
+	assert.Len(t, testnets["a"].Validators, 2)
+}
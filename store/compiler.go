@@ -0,0 +1,220 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/types"
+	"encoding/json"
+	"fmt"
+	"github.com/tendermint/tendermint/crypto"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"time"
+)
+
+// DefaultGenesisCompiler is the name of the compiler used when a testnet does not set genesis_compiler
+const DefaultGenesisCompiler = "default"
+
+// DefaultPower is the genesis voting power of validators that did not set one at registration
+const DefaultPower = 10
+
+// CompilerInput is the testnet definition and the registrations a genesis is compiled from
+type CompilerInput struct {
+	ChainID     string
+	Config      config.TestnetsTOMLConfig
+	GenesisTime time.Time
+	// Genesis validator set picked by the selection policy. Compilers must not modify them.
+	Validators []*ValidatorConfig
+	// Registered nodes outside the genesis validator set, they join as full nodes. Compilers must not modify them.
+	Standby []*ValidatorConfig
+	// Registered sentry, seed and full nodes. Compilers must not modify them.
+	Nodes []*ValidatorConfig
+}
+
+// CompilerOutput is a compiled testnet
+type CompilerOutput struct {
+	Genesis     *tmtypes.GenesisDoc
+	AddressBook *AddrBookJSON
+	// Extra files served next to genesis.json and addrbook.json, by file name
+	Artifacts map[string][]byte
+}
+
+// GenesisCompiler builds the genesis file, the address book and any extra artifacts of a testnet
+type GenesisCompiler interface {
+	Compile(input CompilerInput) (*CompilerOutput, error)
+}
+
+// genesisCompilers lists the compilers selectable by the genesis_compiler testnet option
+var genesisCompilers = map[string]GenesisCompiler{
+	DefaultGenesisCompiler: TendermintGenesisCompiler{},
+	"cosmos-sdk":           CosmosSDKGenesisCompiler{},
+}
+
+// RegisterGenesisCompiler makes a GenesisCompiler selectable by name in the testnet config.
+// It has to be called before the store is created.
+func RegisterGenesisCompiler(name string, compiler GenesisCompiler) {
+	genesisCompilers[name] = compiler
+	config.RegisterGenesisCompilerName(name)
+}
+
+func init() {
+	for name := range genesisCompilers {
+		config.RegisterGenesisCompilerName(name)
+	}
+	for name := range appStateBuilders {
+		config.RegisterAppStateBuilderName(name)
+	}
+}
+
+// GetGenesisCompiler returns the GenesisCompiler registered under name. An empty name returns the default compiler.
+func GetGenesisCompiler(name string) (GenesisCompiler, error) {
+	if name == "" {
+		name = DefaultGenesisCompiler
+	}
+	compiler, ok := genesisCompilers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown genesis compiler %s", name)
+	}
+	return compiler, nil
+}
+
+////////////////////////////////////////////////////////////////
+// Tendermint
+////////////////////////////////////////////////////////////////
+
+// TendermintGenesisCompiler makes the selected validators the genesis validator set, with the power they registered
+// (at most max_power) or DefaultPower. Standby nodes are not validators, they are listed in the address book with
+// the other nodes, except for private nodes. The app_state is built by the app_state_builder of the testnet, if set.
+type TendermintGenesisCompiler struct{}
+
+// Compile implements GenesisCompiler
+func (TendermintGenesisCompiler) Compile(input CompilerInput) (*CompilerOutput, error) {
+	var builder AppStateBuilder
+	if input.Config.AppStateBuilder != "" {
+		var err error
+		builder, err = GetAppStateBuilder(input.Config.AppStateBuilder)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return compileTendermint(input, builder)
+}
+
+// compileTendermint compiles the genesis and the address book, using builder for the app_state
+func compileTendermint(input CompilerInput, builder AppStateBuilder) (*CompilerOutput, error) {
+	appState := json.RawMessage(input.Config.AppState)
+	if builder != nil {
+		var err error
+		appState, err = builder.BuildAppState(appState, genesisAccounts(input))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Generate Genesis
+	var validators []tmtypes.GenesisValidator
+	for _, validator := range input.Validators {
+		key, err := types.PubKeyFromBase64(validator.KeyType, validator.PubKey)
+		if err != nil {
+			// This should not happen, it is checked during registration. (Maybe old data in database.)
+			continue
+		}
+		validators = append(validators, tmtypes.GenesisValidator{
+			Address: key.Address(),
+			Power:   power(validator),
+			PubKey:  key,
+			Name:    validator.Name,
+		})
+	}
+
+	// Generate Address Book, standby nodes are listed next to the other nodes, private nodes are left out
+	addrs := make([]*knownAddress, 0, len(input.Validators)+len(input.Standby)+len(input.Nodes))
+	for _, group := range [][]*ValidatorConfig{input.Validators, input.Standby, input.Nodes} {
+		for _, validator := range group {
+			if validator.Private {
+				continue
+			}
+			addrs = append(addrs, newKnownAddress(validator, input.GenesisTime))
+		}
+	}
+
+	return &CompilerOutput{
+		Genesis: &tmtypes.GenesisDoc{
+			GenesisTime:     input.GenesisTime,
+			ChainID:         input.ChainID,
+			ConsensusParams: input.Config.ConsensusParams(),
+			Validators:      validators,
+			AppState:        appState,
+		},
+		AddressBook: &AddrBookJSON{
+			Key:   crypto.CRandHex(24),
+			Addrs: addrs,
+		},
+	}, nil
+}
+
+// newKnownAddress returns the address book entry of a registered node
+func newKnownAddress(validator *ValidatorConfig, t time.Time) *knownAddress {
+	// The store resolved DNS names again before compiling, see TestnetDB.resolveAddresses
+	address := *validator.NetAddress
+	return &knownAddress{
+		Addr:        &address,
+		Src:         &address,
+		Buckets:     []int{1},
+		Attempts:    0,
+		BucketType:  bucketTypeNew,
+		LastAttempt: t,
+		LastSuccess: t,
+	}
+}
+
+// genesisAccounts collects the application accounts of the registrants.
+// Standby and other nodes get an account too, but only the gentxs of the genesis validator set are included.
+func genesisAccounts(input CompilerInput) []GenesisAccount {
+	accounts := make([]GenesisAccount, 0, len(input.Validators)+len(input.Standby))
+	for _, validator := range input.Validators {
+		if validator.AccountAddress == "" && len(validator.GenTx) == 0 {
+			continue
+		}
+		accounts = append(accounts, GenesisAccount{
+			Address: validator.AccountAddress,
+			Coins:   input.Config.AccountCoins,
+			GenTx:   validator.GenTx,
+		})
+	}
+	for _, group := range [][]*ValidatorConfig{input.Standby, input.Nodes} {
+		for _, validator := range group {
+			if validator.AccountAddress == "" {
+				continue
+			}
+			accounts = append(accounts, GenesisAccount{
+				Address: validator.AccountAddress,
+				Coins:   input.Config.AccountCoins,
+			})
+		}
+	}
+	return accounts
+}
+
+////////////////////////////////////////////////////////////////
+// Cosmos-SDK
+////////////////////////////////////////////////////////////////
+
+// CosmosSDKGenesisCompiler compiles the genesis of a Cosmos-SDK application.
+// Registered accounts and gentxs are merged into the app_state with the CosmosSDKAppStateBuilder.
+// If any gentx was submitted, the validator set is left to the application (as `collect-gentxs` does),
+// otherwise every registrant becomes a validator.
+type CosmosSDKGenesisCompiler struct{}
+
+// Compile implements GenesisCompiler
+func (CosmosSDKGenesisCompiler) Compile(input CompilerInput) (*CompilerOutput, error) {
+	output, err := compileTendermint(input, CosmosSDKAppStateBuilder{})
+	if err != nil {
+		return nil, err
+	}
+	for _, validator := range input.Validators {
+		if len(validator.GenTx) > 0 {
+			output.Genesis.Validators = nil
+			break
+		}
+	}
+	return output, nil
+}
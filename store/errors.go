@@ -0,0 +1,46 @@
+package store
+
+import (
+	"director/m/v2/types"
+	"fmt"
+)
+
+func errUnregisteredTestnet(chainID string) *types.Error {
+	return &types.Error{Code: types.CodeUnregisteredTestnet, Message: "unregistered testnet", Data: types.ErrorData{ChainID: chainID}}
+}
+
+func errTestnetNotReady(chainID string, state types.ServerState) *types.Error {
+	return &types.Error{Code: types.CodeTestnetNotReady, Message: "testnet not ready", Data: types.ErrorData{ChainID: chainID, State: state.String()}}
+}
+
+func errRegistrationClosed(chainID string, state types.ServerState) *types.Error {
+	return &types.Error{Code: types.CodeRegistrationClosed, Message: "testnet not accepting new registrations", Data: types.ErrorData{ChainID: chainID, State: state.String()}}
+}
+
+func errLimitReached(chainID string, limit string, message string) *types.Error {
+	return &types.Error{Code: types.CodeLimitReached, Message: message, Data: types.ErrorData{ChainID: chainID, Limit: limit}}
+}
+
+func errKeyTypeNotAccepted(chainID string, keyType string) *types.Error {
+	return &types.Error{
+		Code:    types.CodeKeyTypeNotAccepted,
+		Message: fmt.Sprintf("key type %s is not accepted on this testnet", keyType),
+		Data:    types.ErrorData{ChainID: chainID, Limit: "pub_key_types"},
+	}
+}
+
+func errRegistrationLocked(chainID string) *types.Error {
+	return &types.Error{Code: types.CodeRegistrationLocked, Message: "genesis validators can't change their registration", Data: types.ErrorData{ChainID: chainID}}
+}
+
+func errUnregisteredNode(chainID string) *types.Error {
+	return &types.Error{Code: types.CodeUnregisteredNode, Message: "unregistered node", Data: types.ErrorData{ChainID: chainID}}
+}
+
+func errInvalidStateChange(chainID string, state types.ServerState, message string) *types.Error {
+	return &types.Error{Code: types.CodeInvalidStateChange, Message: message, Data: types.ErrorData{ChainID: chainID, State: state.String()}}
+}
+
+func errFileNotFound(chainID string, message string) *types.Error {
+	return &types.Error{Code: types.CodeFileNotFound, Message: message, Data: types.ErrorData{ChainID: chainID}}
+}
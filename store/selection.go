@@ -0,0 +1,94 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"sort"
+)
+
+// selectValidators splits the registrations of a testnet into the genesis validator set, the standby nodes
+// and the nodes with other roles, using the selection policy and max_validators of the testnet.
+// The input is sorted by public key.
+func selectValidators(testnetConfig config.TestnetsTOMLConfig, registrations []*ValidatorConfig) (validators []*ValidatorConfig, standby []*ValidatorConfig, nodes []*ValidatorConfig) {
+	candidates := make([]*ValidatorConfig, 0, len(registrations))
+	for _, registration := range registrations {
+		if !registration.IsValidator() {
+			nodes = append(nodes, registration)
+			continue
+		}
+		if testnetConfig.SelectionPolicy == config.SelectionPolicyAdmin && !registration.Selected {
+			standby = append(standby, registration)
+			continue
+		}
+		candidates = append(candidates, registration)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if testnetConfig.SelectionPolicy == config.SelectionPolicyHighestPower && power(candidates[i]) != power(candidates[j]) {
+			return power(candidates[i]) > power(candidates[j])
+		}
+		return candidates[i].RegisteredAt.Before(candidates[j].RegisteredAt)
+	})
+
+	if testnetConfig.MaxValidators > 0 && len(candidates) > int(testnetConfig.MaxValidators) {
+		standby = append(standby, candidates[testnetConfig.MaxValidators:]...)
+		candidates = candidates[:testnetConfig.MaxValidators]
+	}
+	return candidates, standby, nodes
+}
+
+// countCandidates returns the number of registrations counted against required_validators and min_validators
+func countCandidates(testnetConfig config.TestnetsTOMLConfig, registrations map[string]*ValidatorConfig) int {
+	count := 0
+	for _, registration := range registrations {
+		if registration.IsValidator() && (registration.Selected || testnetConfig.SelectionPolicy != config.SelectionPolicyAdmin) {
+			count++
+		}
+	}
+	return count
+}
+
+// power returns the genesis voting power of a registration
+func power(validator *ValidatorConfig) int64 {
+	if validator.Power == 0 {
+		return DefaultPower
+	}
+	return validator.Power
+}
+
+// totalPower returns the total genesis voting power of a validator set. The powers are capped by max_power at
+// registration, so the sum can't overflow.
+func totalPower(validators []*ValidatorConfig) int64 {
+	var total int64
+	for _, validator := range validators {
+		total += power(validator)
+	}
+	return total
+}
+
+// roleLimitOption returns the name of the config option limiting the registrations of a node role
+func roleLimitOption(role string) string {
+	switch role {
+	case RoleSentry:
+		return "max_sentries"
+	case RoleSeed:
+		return "max_seeds"
+	case RoleFull:
+		return "max_full_nodes"
+	default:
+		return "max_validators"
+	}
+}
+
+// roleLimit returns the maximum number of registrations of a node role, 0 - unlimited
+func roleLimit(testnetConfig config.TestnetsTOMLConfig, role string) uint {
+	switch role {
+	case RoleSentry:
+		return testnetConfig.MaxSentries
+	case RoleSeed:
+		return testnetConfig.MaxSeeds
+	case RoleFull:
+		return testnetConfig.MaxFullNodes
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	tmos "github.com/tendermint/tendermint/libs/os"
+	dbm "github.com/tendermint/tm-db"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotTimeFormat names the snapshot files. The names sort in the order the snapshots were taken.
+const snapshotTimeFormat = "20060102T150405.000Z"
+
+// KeySpace is the raw content of a store backend: every key with its encoded value.
+// Snapshots copy the key space, so they work the same way for every backend.
+type KeySpace interface {
+	// Format names the encoding of the values. A snapshot can only be restored into a key space of the same format.
+	Format() string
+	// Export returns every key with its value
+	Export() (map[string][]byte, error)
+	// Import replaces the whole key space with the given keys and values
+	Import(entries map[string][]byte) error
+}
+
+// NewDBKeySpace returns the key space of a tm-db database used by NewStore
+func NewDBKeySpace(db dbm.DB) KeySpace {
+	return &dbBackend{db: db}
+}
+
+// NewJSONKeySpace returns the key space of a directory used by NewJSONStore
+func NewJSONKeySpace(dir string) (KeySpace, error) {
+	b := &jsonBackend{dir: dir}
+	return b, b.ensureDir()
+}
+
+// Snapshot is a copy of a key space at a point in time
+type Snapshot struct {
+	Time    time.Time         `json:"time"`
+	Format  string            `json:"format"`
+	Entries map[string][]byte `json:"entries"`
+}
+
+// Snapshot writes a snapshot of the testnets to dir and deletes the oldest snapshots above retention.
+// A retention of 0 keeps every snapshot. It returns the path of the new snapshot.
+func (s *TestnetDB) Snapshot(dir string, retention int) (string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return WriteSnapshot(s.backend, dir, retention)
+}
+
+// WriteSnapshot writes a snapshot of a key space to dir and deletes the oldest snapshots above retention.
+// A retention of 0 keeps every snapshot. It returns the path of the new snapshot.
+func WriteSnapshot(keySpace KeySpace, dir string, retention int) (string, error) {
+	entries, err := keySpace.Export()
+	if err != nil {
+		return "", err
+	}
+	snapshot := Snapshot{
+		Time:    time.Now().UTC(),
+		Format:  keySpace.Format(),
+		Entries: entries,
+	}
+	content, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	if err := tmos.EnsureDir(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, snapshot.Time.Format(snapshotTimeFormat)+".json")
+	if err := writeFileAtomic(path, content); err != nil {
+		return "", err
+	}
+	return path, pruneSnapshots(dir, retention)
+}
+
+// ListSnapshots returns the names of the snapshots in dir, oldest first
+func ListSnapshots(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if _, err := snapshotTime(file.Name()); err == nil && !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FindSnapshot returns the path of a snapshot. at is a snapshot file, the name of a snapshot in dir,
+// or an RFC3339 time to pick the latest snapshot taken at or before that time.
+func FindSnapshot(dir string, at string) (string, error) {
+	if tmos.FileExists(at) {
+		return at, nil
+	}
+	for _, name := range []string{at, at + ".json"} {
+		if path := filepath.Join(dir, name); tmos.FileExists(path) {
+			return path, nil
+		}
+	}
+	t, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return "", fmt.Errorf("snapshot %s not found in %s", at, dir)
+	}
+	names, err := ListSnapshots(dir)
+	if err != nil {
+		return "", err
+	}
+	for i := len(names) - 1; i >= 0; i-- {
+		taken, _ := snapshotTime(names[i])
+		if !taken.After(t) {
+			return filepath.Join(dir, names[i]), nil
+		}
+	}
+	return "", fmt.Errorf("no snapshot taken at or before %s in %s", at, dir)
+}
+
+// ReadSnapshot reads a snapshot file
+func ReadSnapshot(path string) (*Snapshot, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(content, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %v", path, err)
+	}
+	return snapshot, nil
+}
+
+// RestoreSnapshot replaces the content of a key space with a snapshot. The store must not be running.
+func RestoreSnapshot(keySpace KeySpace, snapshot *Snapshot) error {
+	if snapshot.Format != keySpace.Format() {
+		return fmt.Errorf("snapshot format %s does not match the %s format of the store", snapshot.Format, keySpace.Format())
+	}
+	return keySpace.Import(snapshot.Entries)
+}
+
+// pruneSnapshots deletes the oldest snapshots in dir above retention
+func pruneSnapshots(dir string, retention int) error {
+	if retention == 0 {
+		return nil
+	}
+	names, err := ListSnapshots(dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > retention {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// snapshotTime returns the time a snapshot was taken from its file name
+func snapshotTime(name string) (time.Time, error) {
+	if !strings.HasSuffix(name, ".json") {
+		return time.Time{}, fmt.Errorf("not a snapshot: %s", name)
+	}
+	return time.Parse(snapshotTimeFormat, strings.TrimSuffix(name, ".json"))
+}
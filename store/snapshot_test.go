@@ -0,0 +1,171 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/types"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// storeContent encodes the testnets of a store, to compare stores loaded from different backends
+func storeContent(t *testing.T, s *TestnetDB) map[string]string {
+	content := map[string]string{}
+	for chainID, testnet := range s.testnets {
+		encoded, err := json.Marshal(testnet)
+		require.NoError(t, err)
+		content[chainID] = string(encoded)
+		if testnet.Genesis != nil {
+			genesis, err := tmtypes.GetCodec().MarshalJSON(testnet.Genesis)
+			require.NoError(t, err)
+			content[chainID+" genesis"] = string(genesis)
+		}
+	}
+	return content
+}
+
+func newTempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "director-snapshot")
+	require.NoError(t, err)
+	return dir, func() { os.RemoveAll(dir) } // nolint: errcheck
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	testnets := map[string]config.TestnetsTOMLConfig{
+		"a": {RequiredValidators: 2},
+		"b": {RequiredValidators: 3},
+	}
+	backends := map[string]func(t *testing.T, dir string) (open func() *TestnetDB, keySpace KeySpace){
+		"memdb": func(t *testing.T, dir string) (func() *TestnetDB, KeySpace) {
+			db := dbm.NewMemDB()
+			return func() *TestnetDB { return newTestStoreWithDB(t, db, testnets) }, NewDBKeySpace(db)
+		},
+		"json": func(t *testing.T, dir string) (func() *TestnetDB, KeySpace) {
+			keySpace, err := NewJSONKeySpace(filepath.Join(dir, "testnets"))
+			require.NoError(t, err)
+			return func() *TestnetDB { return NewJSONStore(filepath.Join(dir, "testnets"), testnets) }, keySpace
+		},
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			dir, cleanup := newTempDir(t)
+			defer cleanup()
+			snapshotDir := filepath.Join(dir, "snapshots")
+			open, keySpace := backend(t, dir)
+
+			s := open()
+			require.NoError(t, s.RegisterValidator("a", newTestValidator(t, "a0", "10.0.0.1")))
+			require.NoError(t, s.RegisterValidator("a", newTestValidator(t, "a1", "10.0.0.2")))
+			require.NoError(t, s.RegisterValidator("b", newTestValidator(t, "b0", "10.0.0.3")))
+			require.Equal(t, types.Serve, s.testnets["a"].State)
+			before := storeContent(t, s)
+			path, err := s.Snapshot(snapshotDir, 0)
+			require.NoError(t, err)
+
+			// A mistake to roll back
+			_, err = s.ArchiveTestnet("a")
+			require.NoError(t, err)
+			require.NoError(t, s.RegisterValidator("b", newTestValidator(t, "b1", "10.0.0.4")))
+			require.NotEqual(t, before, storeContent(t, s))
+
+			// The snapshot is found by the time it was taken, as with restore --at
+			found, err := FindSnapshot(snapshotDir, time.Now().UTC().Add(time.Second).Format(time.RFC3339))
+			require.NoError(t, err)
+			assert.Equal(t, path, found)
+			snapshot, err := ReadSnapshot(found)
+			require.NoError(t, err)
+			require.NoError(t, RestoreSnapshot(keySpace, snapshot))
+
+			assert.Equal(t, before, storeContent(t, open()))
+			require.NoError(t, s.Reload())
+			assert.Equal(t, before, storeContent(t, s))
+		})
+	}
+}
+
+func TestRestoreRejectsOtherFormats(t *testing.T) {
+	dir, cleanup := newTempDir(t)
+	defer cleanup()
+	s := newTestStore(t, "a", config.TestnetsTOMLConfig{RequiredValidators: 2})
+	require.NoError(t, s.RegisterValidator("a", newTestValidator(t, "a0", "10.0.0.1")))
+	path, err := s.Snapshot(filepath.Join(dir, "snapshots"), 0)
+	require.NoError(t, err)
+	snapshot, err := ReadSnapshot(path)
+	require.NoError(t, err)
+
+	keySpace, err := NewJSONKeySpace(filepath.Join(dir, "testnets"))
+	require.NoError(t, err)
+	assert.Error(t, RestoreSnapshot(keySpace, snapshot))
+	entries, err := keySpace.Export()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	dir, cleanup := newTempDir(t)
+	defer cleanup()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a snapshot"), 0600))
+	s := newTestStore(t, "a", config.TestnetsTOMLConfig{RequiredValidators: 2})
+
+	var names []string
+	for i := 0; i < 4; i++ {
+		path, err := s.Snapshot(dir, 2)
+		require.NoError(t, err)
+		names = append(names, filepath.Base(path))
+		// snapshot names have a resolution of a millisecond
+		time.Sleep(2 * time.Millisecond)
+	}
+	listed, err := ListSnapshots(dir)
+	require.NoError(t, err)
+	assert.Equal(t, names[2:], listed)
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+
+	// A retention of 0 keeps every snapshot
+	_, err = s.Snapshot(dir, 0)
+	require.NoError(t, err)
+	listed, err = ListSnapshots(dir)
+	require.NoError(t, err)
+	assert.Len(t, listed, 3)
+}
+
+func TestFindSnapshot(t *testing.T) {
+	dir, cleanup := newTempDir(t)
+	defer cleanup()
+	for _, name := range []string{"20200101T000000.000Z.json", "20200101T010000.000Z.json"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(`{}`), 0600))
+	}
+	first := filepath.Join(dir, "20200101T000000.000Z.json")
+	second := filepath.Join(dir, "20200101T010000.000Z.json")
+
+	testCases := []struct {
+		at   string
+		path string
+	}{
+		{second, second},
+		{"20200101T000000.000Z.json", first},
+		{"20200101T000000.000Z", first},
+		{"2020-01-01T00:00:00Z", first},
+		{"2020-01-01T00:59:59Z", first},
+		{"2020-01-01T01:00:00Z", second},
+		{"2020-01-01T02:00:00+01:00", second},
+		{"2021-01-01T00:00:00Z", second},
+	}
+	for _, tc := range testCases {
+		path, err := FindSnapshot(dir, tc.at)
+		require.NoError(t, err, tc.at)
+		assert.Equal(t, tc.path, path, tc.at)
+	}
+
+	for _, at := range []string{"2019-12-31T23:59:59Z", "latest", "20190101T000000.000Z"} {
+		_, err := FindSnapshot(dir, at)
+		assert.Error(t, err, at)
+	}
+}
@@ -1,62 +1,348 @@
 package store
 
 import (
-	"bytes"
 	"director/m/v2/config"
 	"director/m/v2/types"
-	"encoding/base64"
-	"encoding/gob"
-	"errors"
 	"fmt"
-	"github.com/tendermint/tendermint/crypto"
-	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/libs/log"
+	tmrand "github.com/tendermint/tendermint/libs/rand"
+	"github.com/tendermint/tendermint/p2p"
 	tmctypes "github.com/tendermint/tendermint/rpc/core/types"
 	tmtypes "github.com/tendermint/tendermint/types"
 	dbm "github.com/tendermint/tm-db"
+	"net"
+	"sort"
+	"strings"
 	"time"
 )
 
+// Option sets a parameter of the store
+type Option func(*TestnetDB)
+
+// WithResolver sets the resolver looking up the DNS names of the registered nodes.
+// The default is the system resolver.
+func WithResolver(resolver types.Resolver) Option {
+	return func(s *TestnetDB) {
+		s.resolver = resolver
+	}
+}
+
 // NewStore creates a new DB and load the data from the file system.
-func NewStore(db dbm.DB, testnetstomlconfig map[string]config.TestnetsTOMLConfig) *TestnetDB {
-	testnets := map[string]*TestnetConfig{}
-	var err error
-	for key := range testnetstomlconfig {
-		testnets[key], err = loadTestnetConfig(db, key)
-		if err != nil {
-			panic(fmt.Sprintf("error while loading testnet config %s: %v", key, err))
+func NewStore(db dbm.DB, testnetstomlconfig map[string]config.TestnetsTOMLConfig, options ...Option) *TestnetDB {
+	return newTestnetDB(&dbBackend{db: db}, testnetstomlconfig, options...)
+}
+
+// NewJSONStore creates a new DB that keeps every testnet in a plain JSON file of dir.
+func NewJSONStore(dir string, testnetstomlconfig map[string]config.TestnetsTOMLConfig, options ...Option) *TestnetDB {
+	b := &jsonBackend{dir: dir}
+	if err := b.ensureDir(); err != nil {
+		panic(fmt.Sprintf("error while creating store directory %s: %v", dir, err))
+	}
+	return newTestnetDB(b, testnetstomlconfig, options...)
+}
+
+// newTestnetDB loads the testnets of the config from the backend
+func newTestnetDB(b backend, testnetstomlconfig map[string]config.TestnetsTOMLConfig, options ...Option) *TestnetDB {
+	for key, testnetConfig := range testnetstomlconfig {
+		if _, err := GetGenesisCompiler(testnetConfig.GenesisCompiler); err != nil {
+			panic(fmt.Sprintf("error in testnet config %s: %v", key, err))
+		}
+		if testnetConfig.AppStateBuilder != "" {
+			if _, err := GetAppStateBuilder(testnetConfig.AppStateBuilder); err != nil {
+				panic(fmt.Sprintf("error in testnet config %s: %v", key, err))
+			}
 		}
 	}
-	return &TestnetDB{
-		db:        db,
-		testnets:  testnets,
+	s := &TestnetDB{
+		backend:   b,
 		startTime: time.Now(),
 		config:    testnetstomlconfig,
+		resolver:  types.ResolverFunc(net.LookupIP),
+		logger:    log.NewNopLogger(),
+	}
+	for _, option := range options {
+		option(s)
 	}
+	if err := s.Reload(); err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+// SetLogger sets the logger of the store
+func (s *TestnetDB) SetLogger(logger log.Logger) {
+	s.logger = logger
+}
+
+// Reload replaces the testnets in memory with the content of the backend.
+// Directors following the leader of a shared store use it to pick up the changes of the leader.
+func (s *TestnetDB) Reload() error {
+	var testnets map[string]*TestnetConfig
+	var current map[string]string
+	err := s.backend.view(func(load loader) (err error) {
+		testnets = map[string]*TestnetConfig{}
+		current = map[string]string{}
+		for key, testnetConfig := range s.config {
+			if testnetConfig.IsRecurring() {
+				current[key], err = loadInstances(load, testnetConfig, testnets)
+			} else {
+				testnets[key], err = loadTestnetConfig(load, key)
+			}
+			if err != nil {
+				return fmt.Errorf("error while loading testnet config %s: %v", key, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.testnets = testnets
+	s.current = current
+	return nil
 }
 
 // GlobalStateCheck goes through all testnets and set the state when necessary.
-func (s *TestnetDB) GlobalStateCheck() (err error) {
+// A failing testnet does not stop the others from being checked, the errors of all testnets are returned together.
+func (s *TestnetDB) GlobalStateCheck() error {
+	resolved := map[string]types.IP{}
+	for _, chainID := range s.ListTestnets() {
+		s.resolveAddresses(chainID, 0, resolved)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var errs []string
+	for key, testnetConfig := range s.config {
+		if testnetConfig.IsRecurring() {
+			t := s.begin()
+			err := s.checkSchedule(t, key)
+			if err == nil {
+				err = t.commit()
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+			}
+		}
+	}
+	for chainID, testnet := range s.testnets {
+		if testnet.State == types.Gather {
+			t := s.begin()
+			err := s.checkAndChangeStateToServer(t, chainID, resolved)
+			if err == nil {
+				err = t.commit()
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", chainID, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("state check failed for %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ArchiveTestnet closes a testnet. Its files stay available read-only.
+// If the testnet is the latest instance of a recurring testnet, the next instance opens and its chain ID is returned.
+func (s *TestnetDB) ArchiveTestnet(chainID string) (string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
-	for chainID := range s.config {
-		err = s.checkAndChangeStateToServer(chainID)
+	if !s.isRegisteredTestnet(chainID) {
+		return "", errUnregisteredTestnet(chainID)
+	}
+	if s.testnets[chainID].State == types.Archived {
+		return "", errInvalidStateChange(chainID, types.Archived, "testnet already archived")
+	}
+	t := s.begin()
+	testnet := t.update(chainID)
+	testnet.State = types.Archived
+	if testnet.Series == "" || s.current[testnet.Series] != chainID {
+		return "", t.commit()
 	}
-	return
+	if err := s.checkSchedule(t, testnet.Series); err != nil {
+		return "", err
+	}
+	if err := t.commit(); err != nil {
+		return "", err
+	}
+	return s.current[testnet.Series], nil
 }
 
 // RegisterValidator registers a new validator on a testnet in DB.
 func (s *TestnetDB) RegisterValidator(chainID string, validator ValidatorConfig) (err error) {
+	resolved := map[string]types.IP{}
+	s.resolveAddresses(chainID, 1, resolved)
+
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 	if !s.isRegisteredTestnet(chainID) {
-		return errors.New("unregistered testnet")
+		return errUnregisteredTestnet(chainID)
+	}
+	testnet := s.testnets[chainID]
+	testnetConfig := s.configOf(chainID)
+	// After the genesis is compiled, registration stays open for nodes with other roles,
+	// and with a waitlist for standby validators
+	late := testnet.State == types.Serve && (!validator.IsValidator() || testnetConfig.MaxValidators > 0)
+	if testnet.State != types.Gather && !late {
+		return errRegistrationClosed(chainID, testnet.State)
+	}
+	if validator.IsValidator() {
+		keyType := validator.KeyType
+		if keyType == "" {
+			keyType = types.DefaultKeyType
+		}
+		if !testnetConfig.ConsensusParams().Validator.IsValidPubkeyType(keyType) {
+			return errKeyTypeNotAccepted(chainID, keyType)
+		}
+		if validator.Power > testnetConfig.MaxPower {
+			if testnetConfig.MaxPower == 0 {
+				return errLimitReached(chainID, "max_power", "this testnet does not accept a voting power at registration")
+			}
+			return errLimitReached(chainID, "max_power", fmt.Sprintf("voting power can't be greater than %d", testnetConfig.MaxPower))
+		}
+		total := power(&validator)
+		for pubKey, registered := range testnet.Validators {
+			if registered.IsValidator() && pubKey != validator.PubKey {
+				total += power(registered)
+			}
+		}
+		if total > tmtypes.MaxTotalVotingPower {
+			return errLimitReached(chainID, "max_power", "the total voting power of the registrations would exceed the Tendermint maximum")
+		}
+	} else if limit := roleLimit(testnetConfig, validator.Role); limit > 0 {
+		count := 0
+		for pubKey, registered := range testnet.Validators {
+			if registered.Role == validator.Role && pubKey != validator.PubKey {
+				count++
+			}
+		}
+		if count >= int(limit) {
+			return errLimitReached(chainID, roleLimitOption(validator.Role), fmt.Sprintf("testnet not accepting more %s nodes", validator.Role))
+		}
+	}
+
+	// Updating a registration keeps its place in the queue
+	validator.RegisteredAt = time.Now()
+	existing, ok := testnet.Validators[validator.PubKey]
+	if ok {
+		if late && existing.IsValidator() && !existing.Standby {
+			return errRegistrationLocked(chainID)
+		}
+		validator.RegisteredAt = existing.RegisteredAt
+		validator.Selected = existing.Selected
+	}
+	t := s.begin()
+	if late {
+		validator.Standby = validator.IsValidator()
+		t.setValidator(chainID, &validator)
+		if testnet.AddressBook != nil && testnet.Genesis != nil {
+			addToAddressBook(t.update(chainID), &validator, existing)
+		}
+		return t.commit()
+	}
+	t.setValidator(chainID, &validator)
+	if err = s.checkAndChangeStateToServer(t, chainID, resolved); err != nil {
+		return
+	}
+	return t.commit()
+}
+
+// SelectValidator picks or drops a registration for the genesis validator set of a testnet with the admin selection policy.
+func (s *TestnetDB) SelectValidator(chainID string, pubKey string, selected bool) error {
+	resolved := map[string]types.IP{}
+	s.resolveAddresses(chainID, 1, resolved)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.isRegisteredTestnet(chainID) {
+		return errUnregisteredTestnet(chainID)
+	}
+	if s.configOf(chainID).SelectionPolicy != config.SelectionPolicyAdmin {
+		return errInvalidStateChange(chainID, s.testnets[chainID].State, "testnet does not use the admin selection policy")
 	}
 	if s.testnets[chainID].State != types.Gather {
-		return errors.New("testnet not accepting new registrations")
+		return errRegistrationClosed(chainID, s.testnets[chainID].State)
+	}
+	validator, ok := s.testnets[chainID].Validators[pubKey]
+	if !ok || !validator.IsValidator() {
+		return errUnregisteredNode(chainID)
+	}
+	t := s.begin()
+	selection := *validator
+	selection.Selected = selected
+	t.setValidator(chainID, &selection)
+	if err := s.checkAndChangeStateToServer(t, chainID, resolved); err != nil {
+		return err
+	}
+	return t.commit()
+}
+
+// ReopenTestnet restarts the registration period of a failed testnet. Existing registrations are kept.
+func (s *TestnetDB) ReopenTestnet(chainID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.isRegisteredTestnet(chainID) {
+		return errUnregisteredTestnet(chainID)
+	}
+	if s.testnets[chainID].State != types.Failed {
+		return errInvalidStateChange(chainID, s.testnets[chainID].State, "only failed testnets can be reopened")
 	}
-	s.testnets[chainID].Validators[validator.PubKey] = &validator
-	err = s.checkAndChangeStateToServer(chainID)
-	return
+	t := s.begin()
+	testnet := t.update(chainID)
+	testnet.State = types.Gather
+	testnet.OpenedAt = time.Now()
+	testnet.Extensions = 0
+	testnet.TimeoutOutcome = ""
+	return t.commit()
+}
+
+// ListTestnets returns the chain IDs of all testnets in DB, including the archived instances of recurring testnets.
+func (s *TestnetDB) ListTestnets() []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	chainIDs := make([]string, 0, len(s.testnets))
+	for chainID := range s.testnets {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Strings(chainIDs)
+	return chainIDs
+}
+
+// GetStatus gets the registration progress of a testnet from DB.
+func (s *TestnetDB) GetStatus(chainID string) (*TestnetStatus, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if !s.isRegisteredTestnet(chainID) {
+		return nil, errUnregisteredTestnet(chainID)
+	}
+	testnet := s.testnets[chainID]
+	testnetConfig := s.configOf(chainID)
+	validators := 0
+	for _, validator := range testnet.Validators {
+		if validator.IsValidator() {
+			validators++
+		}
+	}
+	result := &TestnetStatus{
+		State:              testnet.State,
+		Validators:         validators,
+		Nodes:              len(testnet.Validators) - validators,
+		Standby:            s.countStandby(chainID),
+		RequiredValidators: testnetConfig.RequiredValidators,
+		MinValidators:      testnetConfig.MinValidators,
+		MaxValidators:      testnetConfig.MaxValidators,
+		Extensions:         testnet.Extensions,
+		TimeoutOutcome:     testnet.TimeoutOutcome,
+		UpdatedAt:          testnet.UpdatedAt,
+	}
+	if testnetConfig.Timeout > 0 {
+		result.Deadline = s.deadline(testnetConfig, testnet)
+	}
+	return result, nil
 }
 
 // GetGenesis gets genesis file from DB.
@@ -64,13 +350,13 @@ func (s *TestnetDB) GetGenesis(chainID string) (*tmctypes.ResultGenesis, error)
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 	if !s.isRegisteredTestnet(chainID) {
-		return nil, errors.New("unregistered testnet")
+		return nil, errUnregisteredTestnet(chainID)
 	}
-	if s.testnets[chainID].State != types.Serve {
-		return nil, errors.New("testnet not ready")
+	if !s.isReadable(chainID) {
+		return nil, errTestnetNotReady(chainID, s.testnets[chainID].State)
 	}
 	if s.testnets[chainID].Genesis == nil {
-		return nil, errors.New("no genesis for testnet")
+		return nil, errFileNotFound(chainID, "no genesis for testnet")
 	}
 	return s.testnets[chainID].Genesis, nil
 }
@@ -80,144 +366,456 @@ func (s *TestnetDB) GetAddressBook(chainID string) (*AddrBookJSON, error) {
 	s.mtx.RLock()
 	defer s.mtx.RUnlock()
 	if !s.isRegisteredTestnet(chainID) {
-		return nil, errors.New("unregistered testnet")
+		return nil, errUnregisteredTestnet(chainID)
 	}
-	if s.testnets[chainID].State != types.Serve {
-		return nil, errors.New("testnet not ready")
+	if !s.isReadable(chainID) {
+		return nil, errTestnetNotReady(chainID, s.testnets[chainID].State)
 	}
 	if s.testnets[chainID].AddressBook == nil {
-		return nil, errors.New("no address book for testnet")
+		return nil, errFileNotFound(chainID, "no address book for testnet")
 	}
 	return s.testnets[chainID].AddressBook, nil
 }
 
-////////////////////////////////////////////////////////////////
-// Internal functions
-////////////////////////////////////////////////////////////////
+// GetArtifact gets an extra file of the genesis compiler from DB.
+func (s *TestnetDB) GetArtifact(chainID string, name string) ([]byte, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if !s.isRegisteredTestnet(chainID) {
+		return nil, errUnregisteredTestnet(chainID)
+	}
+	if !s.isReadable(chainID) {
+		return nil, errTestnetNotReady(chainID, s.testnets[chainID].State)
+	}
+	artifact, ok := s.testnets[chainID].Artifacts[name]
+	if !ok {
+		return nil, errFileNotFound(chainID, "no such file for testnet")
+	}
+	return artifact, nil
+}
 
-// loadTestnetConfig loads testnet config from file into DB
-func loadTestnetConfig(db dbm.DB, chainID string) (*TestnetConfig, error) {
-	result := &TestnetConfig{
-		State:      types.Gather,
-		Validators: map[string]*ValidatorConfig{},
+// GetPeers gets the `id@host:port` addresses of the registered nodes of a testnet,
+// in the format of the `seeds` and `persistent_peers` Tendermint options.
+func (s *TestnetDB) GetPeers(chainID string, options PeersOptions) ([]string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if !s.isRegisteredTestnet(chainID) {
+		return nil, errUnregisteredTestnet(chainID)
+	}
+	if !s.isReadable(chainID) {
+		return nil, errTestnetNotReady(chainID, s.testnets[chainID].State)
+	}
+	return s.peers(chainID, options), nil
+}
+
+// GetNodeConfig gets the Tendermint configuration of a registered node, identified by its public key or node ID.
+func (s *TestnetDB) GetNodeConfig(chainID string, pubKey string, nodeID p2p.ID) (*NodeConfig, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if !s.isRegisteredTestnet(chainID) {
+		return nil, errUnregisteredTestnet(chainID)
+	}
+	if !s.isReadable(chainID) {
+		return nil, errTestnetNotReady(chainID, s.testnets[chainID].State)
 	}
-	testnetconfigbytearray, err := db.Get([]byte(chainID))
+	node := s.findNode(chainID, pubKey, nodeID)
+	if node == nil {
+		return nil, errUnregisteredNode(chainID)
+	}
+
+	testnetConfig := s.configOf(chainID)
+	result, err := testnetConfig.TendermintConfig()
 	if err != nil {
 		return nil, err
 	}
-	if testnetconfigbytearray == nil {
-		return result, nil
+	result.Moniker = node.Name
+	result.P2P.SeedMode = node.Role == RoleSeed
+	result.P2P.ListenAddress = fmt.Sprintf("tcp://0.0.0.0:%d", node.NetAddress.Port)
+	result.P2P.ExternalAddress = node.NetAddress.DialString()
+	result.P2P.PersistentPeers = strings.Join(s.peers(chainID, PeersOptions{Exclude: node.NetAddress.ID}), ",")
+
+	// A private node only connects to its sentries and does not advertise its address
+	if node.Private {
+		var sentries []string
+		for _, sentry := range s.testnets[chainID].Validators {
+			if containsID(node.Sentries, sentry.NetAddress.ID) {
+				sentries = append(sentries, sentry.NetAddress.String())
+			}
+		}
+		sort.Strings(sentries)
+		result.P2P.PersistentPeers = strings.Join(sentries, ",")
+		result.P2P.ExternalAddress = ""
+		result.P2P.PexReactor = false
+		result.P2P.AddrBookStrict = false
+	}
+
+	// A sentry accepts the private nodes it guards and does not gossip their IDs. Only the IDs are listed:
+	// the private node dials its sentries, so the config of a sentry, which anybody can download, never holds
+	// the address of a private node.
+	var guardedIDs []string
+	for _, validator := range s.testnets[chainID].Validators {
+		if validator.Private && containsID(validator.Sentries, node.NetAddress.ID) {
+			guardedIDs = append(guardedIDs, string(validator.NetAddress.ID))
+		}
 	}
-	gob.Register(ed25519.PubKeyEd25519{})
-	dec := gob.NewDecoder(bytes.NewBuffer(testnetconfigbytearray))
-	err = dec.Decode(&result)
+	if len(guardedIDs) > 0 {
+		sort.Strings(guardedIDs)
+		result.P2P.PrivatePeerIDs = strings.Join(guardedIDs, ",")
+		result.P2P.UnconditionalPeerIDs = strings.Join(guardedIDs, ",")
+	}
+
+	return &NodeConfig{
+		NodeID: node.NetAddress.ID,
+		Config: result,
+	}, nil
+}
+
+////////////////////////////////////////////////////////////////
+// Internal functions
+////////////////////////////////////////////////////////////////
+
+// loadTestnetConfig loads testnet config from the backend, or creates a new one
+func loadTestnetConfig(load loader, chainID string) (*TestnetConfig, error) {
+	result, err := load(chainID)
 	if err != nil {
 		return nil, err
 	}
+	if result == nil {
+		result = &TestnetConfig{
+			State:      types.Gather,
+			Validators: map[string]*ValidatorConfig{},
+		}
+	}
 	return result, nil
 }
 
+// loadInstances loads every instance of a recurring testnet into testnets and returns the chain ID of the latest one.
+// Instances are numbered consecutively, so loading stops at the first missing chain ID.
+func loadInstances(load loader, testnetConfig config.TestnetsTOMLConfig, testnets map[string]*TestnetConfig) (latest string, err error) {
+	for instance := 1; ; instance++ {
+		chainID := testnetConfig.InstanceChainID(instance)
+		var testnet *TestnetConfig
+		testnet, err = load(chainID)
+		if err != nil || testnet == nil {
+			return
+		}
+		testnets[chainID] = testnet
+		latest = chainID
+	}
+}
+
 // Not thread safe.
-func (s *TestnetDB) saveTestnetConfig(chainID string, testnetconfig *TestnetConfig) (err error) {
+func (s *TestnetDB) isRegisteredTestnet(chainID string) bool {
+	_, ok := s.testnets[chainID]
+	return ok
+}
 
-	var buffer bytes.Buffer
-	gob.Register(ed25519.PubKeyEd25519{})
-	enc := gob.NewEncoder(&buffer)
-	err = enc.Encode(testnetconfig)
-	if err != nil {
-		return
+// Not thread safe.
+func (s *TestnetDB) configOf(chainID string) config.TestnetsTOMLConfig {
+	if series := s.testnets[chainID].Series; series != "" {
+		return s.config[series]
 	}
-	err = s.db.Set([]byte(chainID), buffer.Bytes())
-	return
+	return s.config[chainID]
 }
 
-// Not thread safe.
-func (s *TestnetDB) saveStore() error {
-	for key, value := range s.testnets {
-		err := s.saveTestnetConfig(key, value)
+// isReadable returns true if the files of a testnet can be served. Not thread safe.
+func (s *TestnetDB) isReadable(chainID string) bool {
+	testnet := s.testnets[chainID]
+	return testnet.State == types.Serve || (testnet.State == types.Archived && testnet.Genesis != nil)
+}
+
+// openedAt returns the start of the registration period of a testnet. Not thread safe.
+func (s *TestnetDB) openedAt(testnet *TestnetConfig) time.Time {
+	if !testnet.OpenedAt.IsZero() {
+		return testnet.OpenedAt
+	}
+	return s.startTime
+}
+
+// deadline returns the end of the registration period of a testnet, including extensions. Not thread safe.
+func (s *TestnetDB) deadline(testnetConfig config.TestnetsTOMLConfig, testnet *TestnetConfig) time.Time {
+	extensions := time.Duration(testnet.Extensions) * testnetConfig.TimeoutExtension
+	return s.openedAt(testnet).Add(testnetConfig.Timeout + extensions)
+}
+
+// compileDue returns true if a testnet may compile its genesis once extra more registrations arrive,
+// because enough validators registered or the timeout passed. Not thread safe.
+func (s *TestnetDB) compileDue(chainID string, extra int) bool {
+	testnet := s.testnets[chainID]
+	if testnet.State != types.Gather {
+		return false
+	}
+	testnetConfig := s.configOf(chainID)
+	if testnetConfig.RequiredValidators > 0 && countCandidates(testnetConfig, testnet.Validators)+extra >= int(testnetConfig.RequiredValidators) {
+		return true
+	}
+	return testnetConfig.Timeout > 0 && !time.Now().Before(s.deadline(testnetConfig, testnet))
+}
+
+// resolveAddresses looks up the DNS names of the nodes of a testnet that may compile its genesis, and adds their IPs
+// to resolved, so the compiled files have the current IPs. The lookups run before the store is locked.
+// If a lookup fails, the node keeps the IP it was registered with.
+func (s *TestnetDB) resolveAddresses(chainID string, extra int, resolved map[string]types.IP) {
+	var names []string
+	s.mtx.RLock()
+	if s.isRegisteredTestnet(chainID) && s.compileDue(chainID, extra) {
+		for _, validator := range s.testnets[chainID].Validators {
+			if validator.NetAddress.Name != "" {
+				names = append(names, validator.NetAddress.Name)
+			}
+		}
+	}
+	s.mtx.RUnlock()
+
+	for _, name := range names {
+		if _, ok := resolved[name]; ok {
+			continue
+		}
+		ip, err := types.LookupIP(s.resolver, name)
 		if err != nil {
-			return err
+			s.logger.Error("Can't resolve node address, keeping the registered IP", "chain_id", chainID, "host", name, "err", err)
+			continue
+		}
+		resolved[name] = types.IP{IP: ip}
+	}
+}
+
+// countStandby returns the number of registrations outside the genesis validator set. Not thread safe.
+func (s *TestnetDB) countStandby(chainID string) int {
+	testnet := s.testnets[chainID]
+	if testnet.State == types.Gather {
+		registrations := make([]*ValidatorConfig, 0, len(testnet.Validators))
+		for _, validator := range testnet.Validators {
+			registrations = append(registrations, validator)
+		}
+		_, standby, _ := selectValidators(s.configOf(chainID), registrations)
+		return len(standby)
+	}
+	count := 0
+	for _, validator := range testnet.Validators {
+		if validator.Standby {
+			count++
 		}
 	}
+	return count
+}
+
+// addToAddressBook adds a node registered late to the address book of a compiled testnet,
+// replacing the entry of its previous registration. Private nodes are left out.
+func addToAddressBook(testnet *TestnetConfig, validator *ValidatorConfig, previous *ValidatorConfig) {
+	if testnet.AddressBook == nil || testnet.Genesis == nil {
+		return
+	}
+	if previous != nil {
+		addrs := make([]*knownAddress, 0, len(testnet.AddressBook.Addrs))
+		for _, addr := range testnet.AddressBook.Addrs {
+			if addr.Addr.ID != previous.NetAddress.ID {
+				addrs = append(addrs, addr)
+			}
+		}
+		testnet.AddressBook.Addrs = addrs
+	}
+	if validator.Private {
+		return
+	}
+	testnet.AddressBook.Addrs = append(testnet.AddressBook.Addrs, newKnownAddress(validator, testnet.Genesis.Genesis.GenesisTime))
+}
+
+// fail marks a testnet failed after its timeout. Not thread safe.
+func fail(t *transition, chainID string) {
+	testnet := t.update(chainID)
+	testnet.State = types.Failed
+	testnet.TimeoutOutcome = TimeoutOutcomeFailed
+}
 
-	// Flush
-	err := s.db.SetSync(nil, nil)
-	return err
+// openInstance creates a new instance of a recurring testnet. Not thread safe.
+func (s *TestnetDB) openInstance(t *transition, key string, instance int, openedAt time.Time) {
+	testnetConfig := s.config[key]
+	chainID := testnetConfig.InstanceChainID(instance)
+	t.create(chainID, &TestnetConfig{
+		State:      types.Gather,
+		Validators: map[string]*ValidatorConfig{},
+		Series:     key,
+		Instance:   instance,
+		OpenedAt:   openedAt,
+	})
+	t.setCurrent(key, chainID)
+}
+
+// checkSchedule opens the first instance of a new recurring testnet, archives the latest instance when its
+// interval passed, and opens the next instance if the latest one is archived. Not thread safe.
+func (s *TestnetDB) checkSchedule(t *transition, key string) error {
+	chainID := t.latest(key)
+	if chainID == "" {
+		s.openInstance(t, key, 1, s.startTime)
+		return nil
+	}
+	testnet := t.read(chainID)
+	interval := s.config[key].Interval
+	now := time.Now()
+	openedAt := now
+	if testnet.State != types.Archived {
+		next := testnet.OpenedAt.Add(interval)
+		if interval == 0 || now.Before(next) {
+			return nil
+		}
+		t.update(chainID).State = types.Archived
+		// Keep the schedule, unless a whole interval was missed (for example the director was not running)
+		if now.Before(next.Add(interval)) {
+			openedAt = next
+		}
+	}
+	s.openInstance(t, key, testnet.Instance+1, openedAt)
+	return nil
 }
 
 // Not thread safe.
-func (s *TestnetDB) isRegisteredTestnet(chainID string) bool {
-	_, ok := s.testnets[chainID]
-	return ok
+func (s *TestnetDB) peers(chainID string, options PeersOptions) []string {
+	peers := make([]string, 0, len(s.testnets[chainID].Validators))
+	for _, validator := range s.testnets[chainID].Validators {
+		if validator.NetAddress.ID == options.Exclude {
+			continue
+		}
+		if options.SeedsOnly && !validator.Seed {
+			continue
+		}
+		if validator.Private {
+			continue
+		}
+		peers = append(peers, validator.NetAddress.String())
+	}
+	sort.Strings(peers)
+
+	if options.Limit > 0 && options.Limit < len(peers) {
+		subset := make([]string, options.Limit)
+		for i, j := range tmrand.Perm(len(peers))[:options.Limit] {
+			subset[i] = peers[j]
+		}
+		peers = subset
+	}
+	return peers
+}
+
+// containsID returns true if id is in ids
+func containsID(ids []p2p.ID, id p2p.ID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
 }
 
 // Not thread safe.
-func (s *TestnetDB) checkAndChangeStateToServer(chainID string) error {
+func (s *TestnetDB) findNode(chainID string, pubKey string, nodeID p2p.ID) *ValidatorConfig {
+	if pubKey != "" {
+		return s.testnets[chainID].Validators[pubKey]
+	}
+	for _, validator := range s.testnets[chainID].Validators {
+		if nodeID != "" && validator.NetAddress.ID == nodeID {
+			return validator
+		}
+	}
+	return nil
+}
+
+// checkAndChangeStateToServer compiles the genesis of a testnet when enough nodes registered or the timeout passed.
+// The registrations get the IPs of their DNS names from resolved. Not thread safe.
+func (s *TestnetDB) checkAndChangeStateToServer(t *transition, chainID string, resolved map[string]types.IP) error {
 	if !s.isRegisteredTestnet(chainID) {
-		return errors.New("unregistered testnet")
+		return errUnregisteredTestnet(chainID)
 	}
 
+	testnetConfig := s.configOf(chainID)
+	testnet := t.read(chainID)
+
 	// Check if need to change state
-	if !(s.testnets[chainID].State != types.Serve &&
-		(len(s.testnets[chainID].Validators) >= int(s.config[chainID].RequiredValidators) ||
-			s.config[chainID].Timeout < time.Since(s.startTime))) {
+	if testnet.State != types.Gather {
 		return nil
 	}
+	registered := countCandidates(testnetConfig, testnet.Validators)
+	timeoutOutcome := ""
+	if testnetConfig.RequiredValidators == 0 || registered < int(testnetConfig.RequiredValidators) {
+		if testnetConfig.Timeout == 0 || time.Now().Before(s.deadline(testnetConfig, testnet)) {
+			return nil
+		}
 
-	// State = Serve
-	s.testnets[chainID].State = types.Serve
-
-	now := time.Now()
+		// The timeout passed, apply the timeout policy
+		minValidators := int(testnetConfig.MinValidators)
+		if minValidators == 0 {
+			minValidators = 1
+		}
+		if registered < minValidators {
+			switch testnetConfig.TimeoutPolicy {
+			case config.TimeoutPolicyExtend:
+				if testnetConfig.MaxExtensions == 0 || testnet.Extensions < int(testnetConfig.MaxExtensions) {
+					next := t.update(chainID)
+					next.Extensions++
+					next.TimeoutOutcome = TimeoutOutcomeExtended
+					return nil
+				}
+				fail(t, chainID)
+				return nil
+			case config.TimeoutPolicyFail:
+				fail(t, chainID)
+				return nil
+			default:
+				if registered == 0 {
+					fail(t, chainID)
+					return nil
+				}
+			}
+		}
+		timeoutOutcome = TimeoutOutcomeCompiled
+	}
 
-	// Generate Genesis
-	var validators []tmtypes.GenesisValidator
+	compiler, err := GetGenesisCompiler(testnetConfig.GenesisCompiler)
+	if err != nil {
+		return err
+	}
 
-	for pubKey, validator := range s.testnets[chainID].Validators {
-		pubBytes, err := base64.StdEncoding.DecodeString(pubKey)
-		if err != nil {
-			// This should not happen, it is checked during registration. (Maybe old data in database.)
-			continue
+	// Sort the registrations, so the genesis does not depend on map order
+	next := t.write(chainID)
+	pubKeys := make([]string, 0, len(next.Validators))
+	for pubKey := range next.Validators {
+		pubKeys = append(pubKeys, pubKey)
+	}
+	sort.Strings(pubKeys)
+	registrations := make([]*ValidatorConfig, 0, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		validator := next.Validators[pubKey]
+		if ip, ok := resolved[validator.NetAddress.Name]; ok {
+			address := *validator.NetAddress
+			address.IP = ip
+			validator.NetAddress = &address
 		}
-		ed := ed25519.PubKeyEd25519{}
-		copy(ed[:], pubBytes)
-		validators = append(validators, tmtypes.GenesisValidator{
-			Address: ed.Address(),
-			Power:   10,
-			PubKey:  ed,
-			Name:    validator.Name,
-		})
-	}
-
-	// Generate Address Book
-	addrs := make([]*knownAddress, 0, len(s.testnets[chainID].Validators))
-	for _, ka := range s.testnets[chainID].Validators {
-		addrs = append(addrs, &knownAddress{
-			Addr:        ka.NetAddress,
-			Src:         ka.NetAddress,
-			Buckets:     []int{1},
-			Attempts:    0,
-			BucketType:  bucketTypeNew,
-			LastAttempt: now,
-			LastSuccess: now,
-		})
-	}
-
-	// If no validators signed up, no genesis or address book is generated
-	if len(validators) > 0 {
-		s.testnets[chainID].AddressBook = &AddrBookJSON{
-			Key:   crypto.CRandHex(24),
-			Addrs: addrs,
-		}
-		s.testnets[chainID].Genesis = &tmctypes.ResultGenesis{
-			Genesis: &tmtypes.GenesisDoc{
-				GenesisTime:     now,
-				ChainID:         chainID,
-				ConsensusParams: tmtypes.DefaultConsensusParams(),
-				Validators:      validators,
-			},
-		}
-	}
-
-	// Save
-	return s.saveStore()
+		registrations = append(registrations, validator)
+	}
+	validators, standby, nodes := selectValidators(testnetConfig, registrations)
+	for _, validator := range standby {
+		validator.Standby = true
+	}
+	if totalPower(validators) > tmtypes.MaxTotalVotingPower {
+		return errLimitReached(chainID, "max_power", "the total voting power of the validator set exceeds the Tendermint maximum")
+	}
+
+	output, err := compiler.Compile(CompilerInput{
+		ChainID:     chainID,
+		Config:      testnetConfig,
+		GenesisTime: time.Now(),
+		Validators:  validators,
+		Standby:     standby,
+		Nodes:       nodes,
+	})
+	if err != nil {
+		return err
+	}
+
+	// State = Serve
+	next.State = types.Serve
+	next.TimeoutOutcome = timeoutOutcome
+	next.Genesis = &tmctypes.ResultGenesis{Genesis: output.Genesis}
+	next.AddressBook = output.AddressBook
+	next.Artifacts = output.Artifacts
+	return nil
 }
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/types"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+)
+
+const (
+	benchTestnets   = 100
+	benchValidators = 500
+)
+
+// BenchmarkRegisterValidator measures the registration throughput of a store with 100 testnets of 500 registrations
+func BenchmarkRegisterValidator(b *testing.B) {
+	b.Run("goleveldb", func(b *testing.B) {
+		dir, err := ioutil.TempDir("", "director-bench")
+		require.NoError(b, err)
+		defer os.RemoveAll(dir) // nolint: errcheck
+		db, err := dbm.NewGoLevelDB("director", dir)
+		require.NoError(b, err)
+		defer db.Close()
+		benchmarkRegisterValidator(b, db)
+	})
+	b.Run("memdb", func(b *testing.B) {
+		benchmarkRegisterValidator(b, dbm.NewMemDB())
+	})
+}
+
+func benchmarkRegisterValidator(b *testing.B, db dbm.DB) {
+	testnetConfigs := map[string]config.TestnetsTOMLConfig{}
+	testnets := map[string]*TestnetConfig{}
+	address := newTestValidator(b, "", "10.0.0.1").NetAddress
+	for i := 0; i < benchTestnets; i++ {
+		chainID := fmt.Sprintf("testnet-%d", i)
+		testnetConfigs[chainID] = config.TestnetsTOMLConfig{RequiredValidators: 1 << 30}
+		testnet := &TestnetConfig{State: types.Gather, OpenedAt: time.Now(), Validators: map[string]*ValidatorConfig{}}
+		for j := 0; j < benchValidators; j++ {
+			pubKey := fmt.Sprintf("%s-validator-%d", chainID, j)
+			testnet.Validators[pubKey] = &ValidatorConfig{Name: pubKey, PubKey: pubKey, NetAddress: address, RegisteredAt: time.Now()}
+		}
+		testnets[chainID] = testnet
+	}
+	require.NoError(b, saveTestnets(&dbBackend{db: db}, testnets))
+	s := newTestStoreWithDB(b, db, testnetConfigs)
+
+	validators := make([]ValidatorConfig, b.N)
+	for i := range validators {
+		validators[i] = newTestValidator(b, fmt.Sprintf("node%d", i), "10.0.0.2")
+	}
+	b.ResetTimer()
+	for i, validator := range validators {
+		if err := s.RegisterValidator(fmt.Sprintf("testnet-%d", i%benchTestnets), validator); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
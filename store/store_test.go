@@ -0,0 +1,272 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/types"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/p2p"
+	tmtypes "github.com/tendermint/tendermint/types"
+	dbm "github.com/tendermint/tm-db"
+)
+
+// newTestStore returns a store in memory with a single testnet
+func newTestStore(t testing.TB, chainID string, testnetConfig config.TestnetsTOMLConfig, options ...Option) *TestnetDB {
+	return newTestStoreWithDB(t, dbm.NewMemDB(), map[string]config.TestnetsTOMLConfig{chainID: testnetConfig}, options...)
+}
+
+func newTestStoreWithDB(t testing.TB, db dbm.DB, testnets map[string]config.TestnetsTOMLConfig, options ...Option) *TestnetDB {
+	for key, testnetConfig := range testnets {
+		require.NoError(t, testnetConfig.ValidateBasic(), key)
+	}
+	return NewStore(db, testnets, options...)
+}
+
+// noResolver fails every lookup, so the tests registering IPs run without a network
+var noResolver = types.ResolverFunc(func(host string) ([]net.IP, error) {
+	return nil, errors.New("unexpected lookup of " + host)
+})
+
+// newTestValidator returns a validator registration with new keys and the IP host
+func newTestValidator(t testing.TB, name string, host string) ValidatorConfig {
+	return newResolvedTestValidator(t, noResolver, name, host)
+}
+
+// newResolvedTestValidator returns a validator registration with new keys. The host can be an IP or a DNS name of resolver.
+func newResolvedTestValidator(t testing.TB, resolver types.Resolver, name string, host string) ValidatorConfig {
+	_, pubKey, err := types.PubKeyToBase64(ed25519.GenPrivKey().PubKey())
+	require.NoError(t, err)
+	nodeID := p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())
+	address, err := types.NewNetAddressString(p2p.IDAddressString(nodeID, net.JoinHostPort(host, "26656")), resolver)
+	require.NoError(t, err)
+	return ValidatorConfig{
+		Name:       name,
+		PubKey:     pubKey,
+		NetAddress: address,
+	}
+}
+
+// fakeResolver resolves host names from a table. Unknown names fail.
+type fakeResolver struct {
+	mtx   sync.Mutex
+	hosts map[string]string
+	calls int
+}
+
+func (r *fakeResolver) LookupIP(host string) ([]net.IP, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.calls++
+	ip, ok := r.hosts[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return []net.IP{net.ParseIP(ip)}, nil
+}
+
+func (r *fakeResolver) set(host string, ip string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.hosts[host] = ip
+}
+
+// addressBookIPs returns the IPs of the address book of a testnet by host name
+func addressBookIPs(t *testing.T, s *TestnetDB, chainID string) map[string]string {
+	addressBook, err := s.GetAddressBook(chainID)
+	require.NoError(t, err)
+	ips := map[string]string{}
+	for _, addr := range addressBook.Addrs {
+		ips[addr.Addr.Host()] = addr.Addr.IP.String()
+	}
+	return ips
+}
+
+func TestCompileResolvesHostNamesAgain(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string]string{"node0.example.com": "10.0.0.1", "node1.example.com": "10.0.0.2"}}
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{RequiredValidators: 2}, WithResolver(resolver))
+
+	require.NoError(t, s.RegisterValidator("test", newResolvedTestValidator(t, resolver, "node0", "node0.example.com")))
+	// The IP of node0 changes between its registration and the compilation
+	resolver.set("node0.example.com", "10.0.1.1")
+	require.NoError(t, s.RegisterValidator("test", newResolvedTestValidator(t, resolver, "node1", "node1.example.com")))
+
+	assert.Equal(t, map[string]string{"node0.example.com": "10.0.1.1", "node1.example.com": "10.0.0.2"}, addressBookIPs(t, s, "test"))
+	peers, err := s.GetPeers("test", PeersOptions{})
+	require.NoError(t, err)
+	for _, peer := range peers {
+		assert.Contains(t, peer, ".example.com:26656")
+	}
+}
+
+func TestCompileKeepsRegisteredIPOnLookupFailure(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string]string{"node0.example.com": "10.0.0.1"}}
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{RequiredValidators: 2}, WithResolver(resolver))
+
+	require.NoError(t, s.RegisterValidator("test", newResolvedTestValidator(t, resolver, "node0", "node0.example.com")))
+	delete(resolver.hosts, "node0.example.com")
+	require.NoError(t, s.RegisterValidator("test", newTestValidator(t, "node1", "10.0.0.2")))
+
+	assert.Equal(t, map[string]string{"node0.example.com": "10.0.0.1", "10.0.0.2": "10.0.0.2"}, addressBookIPs(t, s, "test"))
+}
+
+func TestResolveOnlyBeforeCompiling(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string]string{}}
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{RequiredValidators: 3}, WithResolver(resolver))
+
+	for i := 0; i < 2; i++ {
+		host := "node" + strconv.Itoa(i) + ".example.com"
+		resolver.set(host, "10.0.0."+strconv.Itoa(i+1))
+		require.NoError(t, s.RegisterValidator("test", newResolvedTestValidator(t, resolver, host, host)))
+	}
+	// Only the registrations resolved their own names, the testnet was not ready to compile
+	assert.Equal(t, 2, resolver.calls)
+	require.NoError(t, s.GlobalStateCheck())
+	assert.Equal(t, 2, resolver.calls)
+
+	require.NoError(t, s.RegisterValidator("test", newTestValidator(t, "node2", "10.0.0.3")))
+	assert.Equal(t, 4, resolver.calls)
+	status, err := s.GetStatus("test")
+	require.NoError(t, err)
+	assert.Equal(t, types.Serve, status.State)
+}
+
+func TestResolveAfterTimeout(t *testing.T) {
+	resolver := &fakeResolver{hosts: map[string]string{"node0.example.com": "10.0.0.1"}}
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{Timeout: time.Hour}, WithResolver(resolver))
+
+	require.NoError(t, s.RegisterValidator("test", newResolvedTestValidator(t, resolver, "node0", "node0.example.com")))
+	resolver.set("node0.example.com", "10.0.1.1")
+	s.startTime = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, s.GlobalStateCheck())
+
+	assert.Equal(t, map[string]string{"node0.example.com": "10.0.1.1"}, addressBookIPs(t, s, "test"))
+}
+
+// failingCompiler fails every compilation
+type failingCompiler struct{}
+
+func (failingCompiler) Compile(input CompilerInput) (*CompilerOutput, error) {
+	return nil, errors.New("compiler failed")
+}
+
+func TestGlobalStateCheckReportsEveryTestnet(t *testing.T) {
+	RegisterGenesisCompiler("failing", failingCompiler{})
+	s := newTestStoreWithDB(t, dbm.NewMemDB(), map[string]config.TestnetsTOMLConfig{
+		"alpha": {Timeout: time.Hour, GenesisCompiler: "failing"},
+		"beta":  {Timeout: time.Hour, GenesisCompiler: "failing"},
+		"gamma": {Timeout: time.Hour},
+	})
+	for _, chainID := range []string{"alpha", "beta", "gamma"} {
+		require.NoError(t, s.RegisterValidator(chainID, newTestValidator(t, "node0", "10.0.0.1")))
+	}
+	s.startTime = time.Now().Add(-2 * time.Hour)
+
+	err := s.GlobalStateCheck()
+	assert.EqualError(t, err, "state check failed for alpha: compiler failed; beta: compiler failed")
+	status, err := s.GetStatus("gamma")
+	require.NoError(t, err)
+	assert.Equal(t, types.Serve, status.State)
+}
+
+func TestRegisterValidatorPowerLimits(t *testing.T) {
+	s := newTestStore(t, "capped", config.TestnetsTOMLConfig{RequiredValidators: 4, MaxPower: 100})
+	validator := newTestValidator(t, "node0", "10.0.0.1")
+	validator.Power = 101
+	err := s.RegisterValidator("capped", validator)
+	require.Error(t, err)
+	assert.Equal(t, types.CodeLimitReached, err.(*types.Error).Code)
+	assert.Equal(t, "max_power", err.(*types.Error).Data.Limit)
+	validator.Power = 100
+	assert.NoError(t, s.RegisterValidator("capped", validator))
+
+	s = newTestStore(t, "default", config.TestnetsTOMLConfig{RequiredValidators: 4})
+	validator.Power = 1
+	err = s.RegisterValidator("default", validator)
+	require.Error(t, err)
+	assert.Equal(t, "max_power", err.(*types.Error).Data.Limit)
+	validator.Power = 0
+	assert.NoError(t, s.RegisterValidator("default", validator))
+}
+
+func TestRegisterValidatorTotalPower(t *testing.T) {
+	maxPower := tmtypes.MaxTotalVotingPower / 2
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{
+		RequiredValidators: 3,
+		SelectionPolicy:    config.SelectionPolicyHighestPower,
+		MaxPower:           maxPower,
+	})
+	for i := 0; i < 2; i++ {
+		validator := newTestValidator(t, "node"+strconv.Itoa(i), "10.0.0.1")
+		validator.Power = maxPower
+		require.NoError(t, s.RegisterValidator("test", validator))
+	}
+
+	// A third validator would make the genesis validator set invalid
+	validator := newTestValidator(t, "node2", "10.0.0.3")
+	err := s.RegisterValidator("test", validator)
+	require.Error(t, err)
+	assert.Equal(t, types.CodeLimitReached, err.(*types.Error).Code)
+	status, err := s.GetStatus("test")
+	require.NoError(t, err)
+	assert.Equal(t, types.Gather, status.State)
+}
+
+func TestCompileRejectsInvalidTotalPower(t *testing.T) {
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{Timeout: time.Hour})
+	for i := 0; i < 2; i++ {
+		validator := newTestValidator(t, "node"+strconv.Itoa(i), "10.0.0.1")
+		require.NoError(t, s.RegisterValidator("test", validator))
+		// Registrations stored before max_power existed are not capped
+		s.testnets["test"].Validators[validator.PubKey].Power = tmtypes.MaxTotalVotingPower/2 + 1
+	}
+	s.startTime = time.Now().Add(-2 * time.Hour)
+
+	assert.Error(t, s.GlobalStateCheck())
+	status, err := s.GetStatus("test")
+	require.NoError(t, err)
+	assert.Equal(t, types.Gather, status.State)
+}
+
+func TestSentryConfigHidesPrivateValidator(t *testing.T) {
+	s := newTestStore(t, "test", config.TestnetsTOMLConfig{RequiredValidators: 2})
+	sentry := newTestValidator(t, "sentry", "10.0.0.1")
+	sentry.Role = RoleSentry
+	validator := newTestValidator(t, "validator", "10.0.0.2")
+	validator.Private = true
+	validator.Sentries = []p2p.ID{sentry.NetAddress.ID}
+	public := newTestValidator(t, "public", "10.0.0.3")
+	for _, registration := range []ValidatorConfig{sentry, validator, public} {
+		require.NoError(t, s.RegisterValidator("test", registration))
+	}
+
+	sentryConfig, err := s.GetNodeConfig("test", "", sentry.NetAddress.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(validator.NetAddress.ID), sentryConfig.Config.P2P.PrivatePeerIDs)
+	assert.Equal(t, string(validator.NetAddress.ID), sentryConfig.Config.P2P.UnconditionalPeerIDs)
+	assert.Equal(t, public.NetAddress.String(), sentryConfig.Config.P2P.PersistentPeers)
+
+	validatorConfig, err := s.GetNodeConfig("test", validator.PubKey, "")
+	require.NoError(t, err)
+	assert.Equal(t, sentry.NetAddress.String(), validatorConfig.Config.P2P.PersistentPeers)
+	assert.False(t, validatorConfig.Config.P2P.PexReactor)
+	assert.Empty(t, validatorConfig.Config.P2P.ExternalAddress)
+
+	// The address of the private validator is not published anywhere
+	for _, registration := range []ValidatorConfig{sentry, public} {
+		nodeConfig, err := s.GetNodeConfig("test", registration.PubKey, "")
+		require.NoError(t, err)
+		assert.NotContains(t, nodeConfig.Config.P2P.PersistentPeers, validator.NetAddress.DialString())
+	}
+	peers, err := s.GetPeers("test", PeersOptions{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{sentry.NetAddress.String(), public.NetAddress.String()}, peers)
+	assert.NotContains(t, addressBookIPs(t, s, "test"), "10.0.0.2")
+}
@@ -0,0 +1,125 @@
+package store
+
+import "time"
+
+// transition collects the testnets changed by a state change. Changes are made on copies of the testnets,
+// written to the backend in one batch, and only replace the in-memory testnets once the write succeeded.
+// If the write fails, memory keeps matching the data on disk.
+// The transition keeps track of the parts of the testnets that changed, so backends can write only these parts.
+type transition struct {
+	s       *TestnetDB
+	changed map[string]*testnetChange
+	current map[string]string
+}
+
+// begin starts a state change. Not thread safe.
+func (s *TestnetDB) begin() *transition {
+	return &transition{
+		s:       s,
+		changed: map[string]*testnetChange{},
+		current: map[string]string{},
+	}
+}
+
+// read returns a testnet as changed so far, without copying it
+func (t *transition) read(chainID string) *TestnetConfig {
+	if change, ok := t.changed[chainID]; ok {
+		return change.testnet
+	}
+	return t.s.testnets[chainID]
+}
+
+// change returns the changes of a testnet, starting with a copy that shares its registrations with the original
+func (t *transition) change(chainID string) *testnetChange {
+	if change, ok := t.changed[chainID]; ok {
+		return change
+	}
+	change := &testnetChange{testnet: t.s.testnets[chainID].copy(), validators: map[string]bool{}}
+	t.changed[chainID] = change
+	return change
+}
+
+// update returns the copy of a testnet that can be changed, except for its registrations
+func (t *transition) update(chainID string) *TestnetConfig {
+	change := t.change(chainID)
+	change.fields = true
+	return change.testnet
+}
+
+// setValidator adds or replaces a registration of a testnet
+func (t *transition) setValidator(chainID string, validator *ValidatorConfig) {
+	change := t.change(chainID)
+	change.testnet.Validators[validator.PubKey] = validator
+	change.validators[validator.PubKey] = true
+}
+
+// write returns the copy of a testnet that can be changed, including its registrations
+func (t *transition) write(chainID string) *TestnetConfig {
+	change := t.change(chainID)
+	if !change.allValidators {
+		for pubKey, validator := range change.testnet.Validators {
+			validatorCopy := *validator
+			change.testnet.Validators[pubKey] = &validatorCopy
+		}
+		change.fields = true
+		change.allValidators = true
+	}
+	return change.testnet
+}
+
+// create adds a new testnet
+func (t *transition) create(chainID string, testnet *TestnetConfig) {
+	t.changed[chainID] = &testnetChange{testnet: testnet, fields: true, allValidators: true}
+}
+
+// setCurrent changes the latest instance of a recurring testnet
+func (t *transition) setCurrent(key string, chainID string) {
+	t.current[key] = chainID
+}
+
+// latest returns the latest instance of a recurring testnet as changed so far
+func (t *transition) latest(key string) string {
+	if chainID, ok := t.current[key]; ok {
+		return chainID
+	}
+	return t.s.current[key]
+}
+
+// commit writes the changed testnets to the backend and makes them visible.
+// Every changed testnet gets the time of the commit as UpdatedAt.
+func (t *transition) commit() error {
+	if len(t.changed) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	for _, change := range t.changed {
+		change.testnet.UpdatedAt = now
+		change.fields = true
+	}
+	if err := t.s.backend.save(t.changed); err != nil {
+		return err
+	}
+	for chainID, change := range t.changed {
+		t.s.testnets[chainID] = change.testnet
+	}
+	for key, chainID := range t.current {
+		t.s.current[key] = chainID
+	}
+	return nil
+}
+
+// copy returns a copy of the testnet that shares its registrations with the original.
+// Registrations of the copy have to be replaced, not changed.
+func (c *TestnetConfig) copy() *TestnetConfig {
+	result := *c
+	result.Validators = make(map[string]*ValidatorConfig, len(c.Validators))
+	for pubKey, validator := range c.Validators {
+		result.Validators[pubKey] = validator
+	}
+	if c.AddressBook != nil {
+		addressBook := *c.AddressBook
+		addressBook.Addrs = append([]*knownAddress(nil), c.AddressBook.Addrs...)
+		result.AddressBook = &addressBook
+	}
+	return &result
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"director/m/v2/config"
+	"director/m/v2/types"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tm-db"
+)
+
+var errWriteFailed = errors.New("write failed")
+
+// failingDB is a database whose batches fail to write while fail is set
+type failingDB struct {
+	dbm.DB
+	fail bool
+}
+
+func (db *failingDB) NewBatch() dbm.Batch {
+	return &failingBatch{Batch: db.DB.NewBatch(), db: db}
+}
+
+type failingBatch struct {
+	dbm.Batch
+	db *failingDB
+}
+
+func (b *failingBatch) Write() error {
+	if b.db.fail {
+		return errWriteFailed
+	}
+	return b.Batch.Write()
+}
+
+func (b *failingBatch) WriteSync() error {
+	if b.db.fail {
+		return errWriteFailed
+	}
+	return b.Batch.WriteSync()
+}
+
+func newFailingStore(t *testing.T, testnets map[string]config.TestnetsTOMLConfig) (*TestnetDB, *failingDB) {
+	db := &failingDB{DB: dbm.NewMemDB()}
+	return newTestStoreWithDB(t, db, testnets), db
+}
+
+// requireUnchanged checks that the store still matches its database
+func requireUnchanged(t *testing.T, s *TestnetDB, testnets map[string]*TestnetConfig, current map[string]string) {
+	assert.Equal(t, testnets, s.testnets)
+	assert.Equal(t, current, s.current)
+	require.NoError(t, s.Reload())
+	assert.Equal(t, len(testnets), len(s.testnets))
+	for chainID, testnet := range testnets {
+		require.Contains(t, s.testnets, chainID)
+		assert.Equal(t, testnet.State, s.testnets[chainID].State, chainID)
+		assert.Equal(t, len(testnet.Validators), len(s.testnets[chainID].Validators), chainID)
+	}
+	assert.Equal(t, current, s.current)
+}
+
+// snapshotMemory returns copies of the testnets and the latest instances in memory
+func snapshotMemory(s *TestnetDB) (map[string]*TestnetConfig, map[string]string) {
+	testnets := map[string]*TestnetConfig{}
+	for chainID, testnet := range s.testnets {
+		testnetCopy := *testnet.copy()
+		for pubKey, validator := range testnetCopy.Validators {
+			validatorCopy := *validator
+			testnetCopy.Validators[pubKey] = &validatorCopy
+		}
+		testnets[chainID] = &testnetCopy
+	}
+	current := map[string]string{}
+	for key, chainID := range s.current {
+		current[key] = chainID
+	}
+	return testnets, current
+}
+
+func TestFailedRegistrationKeepsMemory(t *testing.T) {
+	s, db := newFailingStore(t, map[string]config.TestnetsTOMLConfig{"a": {RequiredValidators: 2}})
+	require.NoError(t, s.RegisterValidator("a", newTestValidator(t, "node0", "10.0.0.1")))
+	testnets, current := snapshotMemory(s)
+
+	db.fail = true
+	assert.Equal(t, errWriteFailed, s.RegisterValidator("a", newTestValidator(t, "node1", "10.0.0.2")))
+	requireUnchanged(t, s, testnets, current)
+
+	// The registration goes through once the database works again
+	db.fail = false
+	require.NoError(t, s.RegisterValidator("a", newTestValidator(t, "node1", "10.0.0.2")))
+	assert.Equal(t, types.Serve, s.testnets["a"].State)
+}
+
+func TestFailedCompileKeepsMemory(t *testing.T) {
+	s, db := newFailingStore(t, map[string]config.TestnetsTOMLConfig{"a": {Timeout: 50 * time.Millisecond}})
+	require.NoError(t, s.RegisterValidator("a", newTestValidator(t, "node0", "10.0.0.1")))
+	testnets, current := snapshotMemory(s)
+	time.Sleep(100 * time.Millisecond)
+
+	db.fail = true
+	err := s.GlobalStateCheck()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errWriteFailed.Error())
+	assert.Nil(t, s.testnets["a"].Genesis)
+	requireUnchanged(t, s, testnets, current)
+
+	db.fail = false
+	require.NoError(t, s.GlobalStateCheck())
+	assert.Equal(t, types.Serve, s.testnets["a"].State)
+	assert.NotNil(t, s.testnets["a"].Genesis)
+}
+
+func TestFailedArchiveKeepsMemory(t *testing.T) {
+	s, db := newFailingStore(t, map[string]config.TestnetsTOMLConfig{
+		"game": {ChainIDPattern: "game-{n}", RequiredValidators: 4},
+	})
+	require.NoError(t, s.GlobalStateCheck())
+	require.Equal(t, "game-1", s.current["game"])
+	require.NoError(t, s.RegisterValidator("game-1", newTestValidator(t, "node0", "10.0.0.1")))
+	testnets, current := snapshotMemory(s)
+
+	db.fail = true
+	_, err := s.ArchiveTestnet("game-1")
+	assert.Equal(t, errWriteFailed, err)
+	assert.NotContains(t, s.testnets, "game-2")
+	requireUnchanged(t, s, testnets, current)
+
+	db.fail = false
+	next, err := s.ArchiveTestnet("game-1")
+	require.NoError(t, err)
+	assert.Equal(t, "game-2", next)
+	assert.Equal(t, types.Archived, s.testnets["game-1"].State)
+}
@@ -3,18 +3,45 @@ package store
 import (
 	"director/m/v2/config"
 	"director/m/v2/types"
+	"encoding/json"
+	tmcfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/p2p"
 	tmctypes "github.com/tendermint/tendermint/rpc/core/types"
-	dbm "github.com/tendermint/tm-db"
 	"sync"
 	"time"
 )
 
+// Store is the testnet database the state machine works on
+type Store interface {
+	GlobalStateCheck() error
+	RegisterValidator(chainID string, validator ValidatorConfig) error
+	SelectValidator(chainID string, pubKey string, selected bool) error
+	ArchiveTestnet(chainID string) (string, error)
+	ReopenTestnet(chainID string) error
+	ListTestnets() []string
+	GetStatus(chainID string) (*TestnetStatus, error)
+	GetGenesis(chainID string) (*tmctypes.ResultGenesis, error)
+	GetAddressBook(chainID string) (*AddrBookJSON, error)
+	GetArtifact(chainID string, name string) ([]byte, error)
+	GetPeers(chainID string, options PeersOptions) ([]string, error)
+	GetNodeConfig(chainID string, pubKey string, nodeID p2p.ID) (*NodeConfig, error)
+	Snapshot(dir string, retention int) (string, error)
+	Reload() error
+}
+
+var _ Store = (*TestnetDB)(nil)
+
 // TestnetDB struct for database
 type TestnetDB struct {
-	db        dbm.DB
+	backend   backend
 	testnets  map[string]*TestnetConfig
 	startTime time.Time
 	config    map[string]config.TestnetsTOMLConfig
+	// Chain ID of the latest instance of each recurring testnet, by config section name
+	current  map[string]string
+	resolver types.Resolver
+	logger   log.Logger
 
 	// Use this mutex to indicate access to testnets (Lock or RLock)
 	mtx sync.RWMutex
@@ -22,10 +49,68 @@ type TestnetDB struct {
 
 // TestnetConfig entry in the database
 type TestnetConfig struct {
-	State       types.ServerState `json:"state"`
-	Validators  map[string]*ValidatorConfig
-	Genesis     *tmctypes.ResultGenesis
-	AddressBook *AddrBookJSON
+	State       types.ServerState           `json:"state"`
+	Validators  map[string]*ValidatorConfig `json:"validators"`
+	Genesis     *tmctypes.ResultGenesis     `json:"-"` // encoded separately by the JSON backend
+	AddressBook *AddrBookJSON               `json:"address_book,omitempty"`
+	Artifacts   map[string][]byte           `json:"artifacts,omitempty"`
+
+	// Config section name of a recurring testnet instance, empty for testnets that run once
+	Series   string `json:"series,omitempty"`
+	Instance int    `json:"instance,omitempty"`
+	// Start of the registration period of a recurring testnet instance or a reopened testnet
+	OpenedAt time.Time `json:"opened_at"`
+
+	// Number of times the registration period was extended by the timeout policy
+	Extensions int `json:"extensions,omitempty"`
+	// Outcome of the last timeout, empty if the timeout did not pass yet
+	TimeoutOutcome string `json:"timeout_outcome,omitempty"`
+	// Time of the last committed change, zero for testnets not changed since they were stored without it
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Outcomes of the timeout of a testnet, see config.TestnetsTOMLConfig.TimeoutPolicy
+const (
+	TimeoutOutcomeCompiled = "compiled"
+	TimeoutOutcomeExtended = "extended"
+	TimeoutOutcomeFailed   = "failed"
+)
+
+// TestnetStatus is the registration progress of a testnet
+type TestnetStatus struct {
+	State              types.ServerState
+	Validators         int
+	RequiredValidators uint
+	MinValidators      uint
+	MaxValidators      uint
+	// Number of registrations on the waitlist, or outside the genesis validator set once compiled
+	Standby int
+	// Number of sentry, seed and full node registrations
+	Nodes int
+	// End of the registration period, zero if the testnet has no timeout
+	Deadline       time.Time
+	Extensions     int
+	TimeoutOutcome string
+	// Time of the last change of the testnet, including late registrations
+	UpdatedAt time.Time
+}
+
+// Roles of registered nodes. Only validators go into the genesis validator set.
+const (
+	RoleValidator = "validator"
+	RoleSentry    = "sentry"
+	RoleSeed      = "seed"
+	RoleFull      = "full"
+)
+
+// IsValidRole returns true for the known node roles. Empty means validator.
+func IsValidRole(role string) bool {
+	switch role {
+	case "", RoleValidator, RoleSentry, RoleSeed, RoleFull:
+		return true
+	default:
+		return false
+	}
 }
 
 // ValidatorConfig entry in the database
@@ -33,4 +118,47 @@ type ValidatorConfig struct {
 	NetAddress *types.NetAddress `json:"net_address"`
 	Name       string            `json:"name"`
 	PubKey     string            `json:"pub_key"`
+	KeyType    string            `json:"key_type"`
+	Seed       bool              `json:"seed"`
+
+	// Application account of the registrant and its optional genesis transaction
+	AccountAddress string          `json:"account_address,omitempty"`
+	GenTx          json.RawMessage `json:"gentx,omitempty"`
+
+	// Genesis voting power, 0 - default power
+	Power        int64     `json:"power,omitempty"`
+	RegisteredAt time.Time `json:"registered_at"`
+	// Picked for the genesis validator set by an admin, with the admin selection policy
+	Selected bool `json:"selected,omitempty"`
+	// Registered node that is not in the genesis validator set, it joins as a full node
+	Standby bool `json:"standby,omitempty"`
+
+	// validator | sentry | seed | full, empty means validator
+	Role string `json:"role,omitempty"`
+
+	// Node IDs of the sentries guarding the node
+	Sentries []p2p.ID `json:"sentries,omitempty"`
+	// The address of a private node is only given to its sentries. It is left out of the address book and the peer lists.
+	Private bool `json:"private,omitempty"`
+}
+
+// IsValidator returns true if the node registered for the genesis validator set
+func (v *ValidatorConfig) IsValidator() bool {
+	return v.Role == "" || v.Role == RoleValidator
+}
+
+// PeersOptions filters the peer list of a testnet
+type PeersOptions struct {
+	// Node ID left out of the list, usually the caller's own
+	Exclude p2p.ID
+	// Random subset of this size, 0 - all peers
+	Limit int
+	// Only include nodes registered as seeds
+	SeedsOnly bool
+}
+
+// NodeConfig is the generated Tendermint configuration of a registered node
+type NodeConfig struct {
+	NodeID p2p.ID
+	Config *tmcfg.Config
 }
@@ -0,0 +1,38 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// coinRegexp matches a single coin of a coin list, for example 1000stake
+var coinRegexp = regexp.MustCompile(`^([0-9]+)([a-z][a-z0-9/]{2,127})$`)
+
+// Coin is an amount of a token in the Cosmos-SDK format
+type Coin struct {
+	Denom  string
+	Amount string
+}
+
+// ParseCoins parses a "1000stake,5token" coin list, sorted by denomination as the Cosmos-SDK requires
+func ParseCoins(coinsStr string) ([]Coin, error) {
+	coins := []Coin{}
+	for _, coinStr := range strings.Split(coinsStr, ",") {
+		coinStr = strings.TrimSpace(coinStr)
+		if coinStr == "" {
+			continue
+		}
+		matches := coinRegexp.FindStringSubmatch(coinStr)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid coin %s", coinStr)
+		}
+		coins = append(coins, Coin{
+			Denom:  matches[2],
+			Amount: matches[1],
+		})
+	}
+	sort.Slice(coins, func(i, j int) bool { return coins[i].Denom < coins[j].Denom })
+	return coins, nil
+}
@@ -0,0 +1,97 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode identifies the kind of an error returned by the director. The codes are part of the API and never change.
+//
+// Errors of the testnets are numbered from 1001 and errors of the requests from 1101. The codes of the
+// JSON-RPC 2.0 specification are kept for requests that are not valid JSON-RPC and for internal errors.
+type ErrorCode int
+
+const (
+	// CodeUnregisteredTestnet is returned for chain IDs the director does not know
+	CodeUnregisteredTestnet ErrorCode = 1001
+	// CodeTestnetNotReady is returned when the files of a testnet are requested before the genesis is compiled
+	CodeTestnetNotReady ErrorCode = 1002
+	// CodeRegistrationClosed is returned for registrations the testnet does not accept in its state
+	CodeRegistrationClosed ErrorCode = 1003
+	// CodeLimitReached is returned when a registration would exceed a limit of the testnet config
+	CodeLimitReached ErrorCode = 1004
+	// CodeKeyTypeNotAccepted is returned for validator keys of a type the testnet does not accept
+	CodeKeyTypeNotAccepted ErrorCode = 1005
+	// CodeRegistrationLocked is returned when a genesis validator tries to change its registration
+	CodeRegistrationLocked ErrorCode = 1006
+	// CodeUnregisteredNode is returned for nodes that did not register on the testnet
+	CodeUnregisteredNode ErrorCode = 1007
+	// CodeInvalidStateChange is returned for admin actions the testnet does not allow in its state or config
+	CodeInvalidStateChange ErrorCode = 1008
+	// CodeFileNotFound is returned for files the testnet does not have
+	CodeFileNotFound ErrorCode = 1009
+
+	// CodeInvalidParam is returned for requests with an invalid parameter
+	CodeInvalidParam ErrorCode = 1101
+	// CodeTooManyRequests is returned to throttled or banned clients
+	CodeTooManyRequests ErrorCode = 1102
+	// CodeNotLeader is returned by followers for requests that change the store
+	CodeNotLeader ErrorCode = 1103
+
+	// CodeParseError is returned for request bodies that are not valid JSON
+	CodeParseError ErrorCode = -32700
+	// CodeInvalidRequest is returned for requests that are not valid JSON-RPC
+	CodeInvalidRequest ErrorCode = -32600
+	// CodeMethodNotFound is returned for unknown methods and paths
+	CodeMethodNotFound ErrorCode = -32601
+	// CodeInternalError is returned for untyped errors
+	CodeInternalError ErrorCode = -32603
+)
+
+// Error is an error with a stable code and the details a client needs to act on it.
+// It is the error object of the JSON-RPC and REST responses.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Data    ErrorData `json:"data"`
+}
+
+// ErrorData holds the machine-readable details of an error. Only the fields relevant to the error are set.
+type ErrorData struct {
+	ChainID string `json:"chain_id,omitempty"`
+	// State of the testnet
+	State string `json:"state,omitempty"`
+	// Config option of the testnet that was reached, for example max_seeds
+	Limit string `json:"limit,omitempty"`
+	// Invalid request parameter
+	Param string `json:"param,omitempty"`
+	// Advertised address of the leader a follower sends writes to
+	Leader string `json:"leader,omitempty"`
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// NewError returns an error without details
+func NewError(code ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// AsError returns the typed error in the chain of err, if there is one
+func AsError(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// ToError returns the typed error in the chain of err, or an internal error with the text of err
+func ToError(err error) *Error {
+	if e, ok := AsError(err); ok {
+		return e
+	}
+	return NewError(CodeInternalError, "%s", err.Error())
+}
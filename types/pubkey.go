@@ -0,0 +1,63 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	"github.com/tendermint/tendermint/crypto/sr25519"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// DefaultKeyType is the validator key type used when none is given
+const DefaultKeyType = tmtypes.ABCIPubKeyTypeEd25519
+
+// PubKeyFromBase64 decodes a base64 encoded validator public key of the given type.
+// Supported types are ed25519, secp256k1 and sr25519. An empty type defaults to ed25519.
+func PubKeyFromBase64(keyType string, pubKey string) (crypto.PubKey, error) {
+	pubBytes, err := base64.StdEncoding.DecodeString(pubKey)
+	if err != nil {
+		return nil, err
+	}
+	switch keyType {
+	case "", tmtypes.ABCIPubKeyTypeEd25519:
+		if len(pubBytes) != ed25519.PubKeyEd25519Size {
+			return nil, errors.New("invalid ed25519 public key length")
+		}
+		var key ed25519.PubKeyEd25519
+		copy(key[:], pubBytes)
+		return key, nil
+	case tmtypes.ABCIPubKeyTypeSecp256k1:
+		if len(pubBytes) != secp256k1.PubKeySecp256k1Size {
+			return nil, errors.New("invalid secp256k1 public key length")
+		}
+		var key secp256k1.PubKeySecp256k1
+		copy(key[:], pubBytes)
+		return key, nil
+	case tmtypes.ABCIPubKeyTypeSr25519:
+		if len(pubBytes) != sr25519.PubKeySr25519Size {
+			return nil, errors.New("invalid sr25519 public key length")
+		}
+		var key sr25519.PubKeySr25519
+		copy(key[:], pubBytes)
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unknown key type %s", keyType)
+	}
+}
+
+// PubKeyToBase64 returns the key type and the base64 encoded raw bytes of a validator public key
+func PubKeyToBase64(pubKey crypto.PubKey) (keyType string, value string, err error) {
+	switch key := pubKey.(type) {
+	case ed25519.PubKeyEd25519:
+		return tmtypes.ABCIPubKeyTypeEd25519, base64.StdEncoding.EncodeToString(key[:]), nil
+	case secp256k1.PubKeySecp256k1:
+		return tmtypes.ABCIPubKeyTypeSecp256k1, base64.StdEncoding.EncodeToString(key[:]), nil
+	case sr25519.PubKeySr25519:
+		return tmtypes.ABCIPubKeyTypeSr25519, base64.StdEncoding.EncodeToString(key[:]), nil
+	default:
+		return "", "", fmt.Errorf("unsupported public key type %T", pubKey)
+	}
+}
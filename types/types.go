@@ -2,8 +2,12 @@ package types
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"github.com/tendermint/tendermint/p2p"
 	"net"
+	"strconv"
+	"strings"
 )
 
 // ServerState defines the state machine state type
@@ -14,8 +18,48 @@ const (
 	Gather ServerState = iota
 	// Serve state
 	Serve
+	// Archived state of a closed testnet. Its files stay available read-only.
+	Archived
+	// Failed state of a testnet that timed out without enough registrations
+	Failed
 )
 
+// String returns the name of the state as reported by the status endpoint
+func (s ServerState) String() string {
+	switch s {
+	case Gather:
+		return "gather"
+	case Serve:
+		return "serve"
+	case Archived:
+		return "archived"
+	case Failed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalJSON encodes the state by name
+func (s ServerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON decodes a state name
+func (s *ServerState) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for state := Gather; state <= Failed; state++ {
+		if state.String() == name {
+			*s = state
+			return nil
+		}
+	}
+	return errors.New("unknown state " + name)
+}
+
 ////////////////////////////////////////////////////////////////
 // Copied from tendermint/tendermint@0.33.0/p2p/netaddress.go (because of https://gitlab.com/testnetkitchen/director/issues/11)
 ////////////////////////////////////////////////////////////////
@@ -27,8 +71,7 @@ type NetAddress struct {
 	IP   IP     `json:"ip"`
 	Port uint16 `json:"port"`
 
-	// TODO:
-	// Name string `json:"name"` // optional DNS name
+	Name string `json:"name"` // optional DNS name
 
 	// memoize .String()
 	str string
@@ -54,11 +97,37 @@ func (ip *IP) UnmarshalJSON(text []byte) error {
 	return ip.UnmarshalText(trimmed)
 }
 
+// Resolver looks up the IP addresses of a host name
+type Resolver interface {
+	LookupIP(host string) ([]net.IP, error)
+}
+
+// ResolverFunc is an adapter to use a function as a Resolver
+type ResolverFunc func(host string) ([]net.IP, error)
+
+// LookupIP implements Resolver
+func (f ResolverFunc) LookupIP(host string) ([]net.IP, error) {
+	return f(host)
+}
+
 // NewNetAddressString returns a new NetAddress using the provided address in
-// the form of "ID@IP:Port".
-// Also resolves the host if host is not an IP.
+// the form of "ID@Host:Port".
+// Also resolves the host with resolver if host is not an IP, and keeps the host name.
+// ResolverFunc(net.LookupIP) resolves with the system resolver.
 // Errors are of type ErrNetAddressXxx where Xxx is in (NoID, Invalid, Lookup)
-func NewNetAddressString(addr string) (*NetAddress, error) {
+func NewNetAddressString(addr string, resolver Resolver) (*NetAddress, error) {
+	var name string
+	if spl := strings.Split(addr, "@"); len(spl) == 2 {
+		if host, port, err := net.SplitHostPort(spl[1]); err == nil && host != "" && net.ParseIP(host) == nil {
+			ip, err := LookupIP(resolver, host)
+			if err != nil {
+				return nil, err
+			}
+			name = host
+			addr = p2p.IDAddressString(p2p.ID(spl[0]), net.JoinHostPort(ip.String(), port))
+		}
+	}
+
 	// Convert NetAddress to p2p.NetAddress
 	original, err := p2p.NewNetAddressString(addr)
 	if err != nil {
@@ -70,11 +139,39 @@ func NewNetAddressString(addr string) (*NetAddress, error) {
 			IP: original.IP,
 		},
 		Port: original.Port,
+		Name: name,
 		str:  "",
 	}
 	return converted, nil
 }
 
+// Host returns the DNS name of the address, or its IP if it has no name
+func (na *NetAddress) Host() string {
+	if na.Name != "" {
+		return na.Name
+	}
+	return na.IP.String()
+}
+
+// String representation: <ID>@<Host>:<PORT>
+func (na *NetAddress) String() string {
+	if na == nil {
+		return "<nil-NetAddress>"
+	}
+	return p2p.IDAddressString(na.ID, na.DialString())
+}
+
+// DialString returns the <Host>:<PORT> part of the address
+func (na *NetAddress) DialString() string {
+	if na == nil {
+		return "<nil-NetAddress>"
+	}
+	return net.JoinHostPort(
+		na.Host(),
+		strconv.FormatUint(uint64(na.Port), 10),
+	)
+}
+
 // Valid implements net.IP.Valid
 func (na *NetAddress) Valid() error {
 	// Convert NetAddress to p2p.NetAddress
@@ -86,4 +183,16 @@ func (na *NetAddress) Valid() error {
 	return p2pNetAddress.Valid()
 }
 
+// LookupIP resolves a host name to its first IP
+func LookupIP(resolver Resolver, host string) (net.IP, error) {
+	ips, err := resolver.LookupIP(host)
+	if err != nil {
+		return nil, p2p.ErrNetAddressLookup{Addr: host, Err: err}
+	}
+	if len(ips) == 0 {
+		return nil, p2p.ErrNetAddressLookup{Addr: host, Err: errors.New("no IP address found")}
+	}
+	return ips[0], nil
+}
+
 ////////////////////////////////////////////////////////////////
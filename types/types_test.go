@@ -0,0 +1,42 @@
+package types
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testID = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+
+func TestNewNetAddressStringKeepsHostName(t *testing.T) {
+	resolver := ResolverFunc(func(host string) ([]net.IP, error) {
+		if host != "node.example.com" {
+			return nil, errors.New("no such host")
+		}
+		return []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}, nil
+	})
+
+	address, err := NewNetAddressString(testID+"@node.example.com:26656", resolver)
+	require.NoError(t, err)
+	assert.Equal(t, "node.example.com", address.Name)
+	assert.Equal(t, "10.0.0.1", address.IP.String())
+	assert.Equal(t, testID+"@node.example.com:26656", address.String())
+
+	_, err = NewNetAddressString(testID+"@unknown.example.com:26656", resolver)
+	assert.Error(t, err)
+}
+
+func TestNewNetAddressStringWithIP(t *testing.T) {
+	resolver := ResolverFunc(func(host string) ([]net.IP, error) {
+		t.Fatalf("unexpected lookup of %s", host)
+		return nil, nil
+	})
+
+	address, err := NewNetAddressString(testID+"@10.0.0.1:26656", resolver)
+	require.NoError(t, err)
+	assert.Equal(t, "", address.Name)
+	assert.Equal(t, testID+"@10.0.0.1:26656", address.String())
+}